@@ -21,14 +21,20 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"os"
 
 	"github.com/kloeckner-i/can-haz-password/password"
 )
 
 const (
+	// unambiguousLetters and unambiguousDigits exclude password.AmbiguousCharacters (O/0, I/l/1) at the
+	// pool level, rather than via a rejecting password.RuleFilter. Entropy-driven length growth
+	// (-min-entropy) can push the generator into drawing many candidates against a fixed rejection
+	// budget, and a filter that rejects whole candidates containing an excluded character multiplies
+	// that rejection rate; restricting the pool up front keeps every draw valid instead.
 	unambiguousLetters = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
 	unambiguousDigits  = "23456789"
-	// Widely compatible and unambiguous characters.
+	// Widely compatible and unambiguous special characters.
 	specialCharacters = "_-@!*."
 )
 
@@ -36,45 +42,90 @@ const (
 func main() {
 	passwordLength := flag.Int("length", 8, "minimum length of the generated password")
 	includeSpecialCharacters := flag.Bool("special", true, "include special characters")
+	minEntropyBits := flag.Float64("min-entropy", 0, "keep growing the password past -length until it "+
+		"reaches at least this many bits of entropy (0 disables this and uses -length as-is)")
+	hashScheme := flag.String("hash", "", "also hash the generated password with the named scheme "+
+		"(bcrypt, scrypt, argon2id, pbkdf2-sha256, pbkdf2-sha512) and print the encoded hash alongside it")
 
 	flag.Parse()
 
 	generator := password.NewGenerator(&demoPasswordRule{
 		length:            passwordLength,
 		specialCharacters: includeSpecialCharacters,
+		minEntropyBits:    minEntropyBits,
 	})
 
-	password, err := generator.Generate()
+	generated, bits, err := generator.GenerateWithEntropy()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Println(password)
+	fmt.Println(generated)
+	fmt.Fprintf(os.Stderr, "entropy: %.1f bits\n", bits)
+
+	if *hashScheme == "" {
+		return
+	}
+
+	hasher, ok := hasherForScheme(password.HashScheme(*hashScheme))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown hash scheme %q\n", *hashScheme)
+		os.Exit(1)
+	}
+
+	encoded, err := password.Hash(generated, hasher)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(encoded)
+}
+
+// hasherForScheme maps a -hash flag value to the Hasher that implements it, using each scheme's default
+// cost parameters.
+func hasherForScheme(scheme password.HashScheme) (password.Hasher, bool) {
+	switch scheme {
+	case password.HashSchemeBcrypt:
+		return password.BcryptHasher{}, true
+	case password.HashSchemeScrypt:
+		return password.ScryptHasher{}, true
+	case password.HashSchemeArgon2id:
+		return password.Argon2idHasher{}, true
+	case password.HashSchemePBKDF2SHA256:
+		return password.PBKDF2SHA256Hasher{}, true
+	case password.HashSchemePBKDF2SHA512:
+		return password.PBKDF2SHA512Hasher{}, true
+	default:
+		return nil, false
+	}
 }
 
 type demoPasswordRule struct {
 	length            *int
 	specialCharacters *bool
+	minEntropyBits    *float64
 }
 
 func (r *demoPasswordRule) Config() *password.Configuration {
 	passwordLength := *r.length
 	classes := []password.CharacterClassConfiguration{
 		// Typically the minimums would be constants, in this case due to varying minimum lengths
-		// we set them as relative percentages of the total length.
-		{Characters: unambiguousLetters, Minimum: int(math.Ceil(float64(passwordLength) * 0.5))},
-		{Characters: unambiguousDigits, Minimum: int(math.Ceil(float64(passwordLength) * 0.33))},
+		// we set them as relative percentages of the total length. math.Floor, not math.Ceil, keeps the
+		// minimums from summing past passwordLength itself (their percentages already add up to 1.0).
+		{Characters: unambiguousLetters, Minimum: int(math.Floor(float64(passwordLength) * 0.5))},
+		{Characters: unambiguousDigits, Minimum: int(math.Floor(float64(passwordLength) * 0.33))},
 	}
 
 	if *r.specialCharacters {
 		classes = append(classes, password.CharacterClassConfiguration{
 			Characters: specialCharacters,
-			Minimum:    int(math.Ceil(float64(passwordLength) * 0.17))})
+			Minimum:    int(math.Floor(float64(passwordLength) * 0.17))})
 	}
 
 	return &password.Configuration{
-		Length:           passwordLength,
-		CharacterClasses: classes,
+		Length:             passwordLength,
+		CharacterClasses:   classes,
+		MinimumEntropyBits: *r.minEntropyBits,
 	}
 }
 
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// pwverify checks a candidate password against a PHC-style encoded hash produced by password.Hash,
+// exiting 0 if it matches and 1 if it doesn't, so it can be dropped straight into a shell pipeline.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+)
+
+// passEnvVar is the environment variable pwverify falls back to for the candidate password when neither
+// -pass nor -pass-file is given, so a password never has to appear in a shell history or process listing.
+const passEnvVar = "PWGEN_PASS"
+
+func main() {
+	encodedHash := flag.String("hash", "", "PHC-style encoded hash to verify against")
+	encodedHashFile := flag.String("hash-file", "", "path to a file containing the PHC-style encoded hash")
+	candidatePass := flag.String("pass", "", "candidate password to verify")
+	candidatePassFile := flag.String("pass-file", "", "path to a file containing the candidate password")
+
+	flag.Parse()
+
+	encoded, err := resolveValue(*encodedHash, *encodedHashFile, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pwverify: no encoded hash given; use -hash or -hash-file")
+		os.Exit(2)
+	}
+
+	candidate, err := resolveValue(*candidatePass, *candidatePassFile, passEnvVar)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pwverify: no candidate password given; use -pass, -pass-file, or %s\n", passEnvVar)
+		os.Exit(2)
+	}
+
+	ok, err := password.Verify(encoded, candidate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pwverify: %v\n", err)
+		os.Exit(2)
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// resolveValue picks a value from, in order of precedence, a flag value, a file to read it from, or an
+// environment variable, returning an error if none of the three is set.
+func resolveValue(flagValue, filePath, envVar string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	if filePath != "" {
+		contents, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(contents), "\r\n"), nil
+	}
+
+	if envVar != "" {
+		if value, ok := os.LookupEnv(envVar); ok {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("no value given")
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+)
+
+// ErrPasswordBreached is returned by BloomFilterValidator when a password probably appears in the seeded
+// breach word list.
+var ErrPasswordBreached = errors.New("password probably appears in the breach word list")
+
+// ErrInvalidBloomFilterParameters is returned by NewBloomFilterValidator when expectedEntries is not
+// positive, or falsePositiveRate is not strictly between 0 and 1, either of which makes the optimal bit
+// array size and hash count undefined.
+var ErrInvalidBloomFilterParameters = errors.New(
+	"password: expectedEntries must be positive and falsePositiveRate must be between 0 and 1")
+
+// BloomFilterValidator offers an offline alternative to HIBPValidator, rejecting passwords that match
+// entries seeded from a breach word list into a Bloom filter [1]. Suitable for air-gapped environments
+// where calling out to the HIBP API is not possible. Being a Bloom filter, false positives are possible by
+// construction; false negatives are not.
+//
+// [1] https://en.wikipedia.org/wiki/Bloom_filter
+type BloomFilterValidator struct {
+	bits   []uint64
+	size   uint64
+	hashes int
+}
+
+// NewBloomFilterValidator constructs a BloomFilterValidator from words, a reader yielding one password per
+// line (eg. a breach word list file), sized for expectedEntries at the given falsePositiveRate.
+func NewBloomFilterValidator(words io.Reader, expectedEntries int, falsePositiveRate float64) (*BloomFilterValidator, error) {
+	if expectedEntries <= 0 || falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, ErrInvalidBloomFilterParameters
+	}
+
+	size, hashes := bloomParameters(expectedEntries, falsePositiveRate)
+
+	v := &BloomFilterValidator{
+		bits:   make([]uint64, (size/64)+1),
+		size:   size,
+		hashes: hashes,
+	}
+
+	scanner := bufio.NewScanner(words)
+	for scanner.Scan() {
+		v.add(scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("bloom filter validator: %w", err)
+	}
+
+	return v, nil
+}
+
+// Name identifies this validator.
+func (v *BloomFilterValidator) Name() string {
+	return "bloom-filter"
+}
+
+// Validate returns ErrPasswordBreached if the password probably appears in the seeded word list.
+func (v *BloomFilterValidator) Validate(password string) error {
+	if v.contains(password) {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
+
+func (v *BloomFilterValidator) add(word string) {
+	for _, index := range v.indexesFor(word) {
+		v.bits[index/64] |= 1 << (index % 64)
+	}
+}
+
+func (v *BloomFilterValidator) contains(word string) bool {
+	for _, index := range v.indexesFor(word) {
+		if v.bits[index/64]&(1<<(index%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// indexesFor computes v.hashes bit indexes for word using double hashing (Kirsch-Mitzenmacher), which
+// avoids the need for v.hashes independent hash functions.
+func (v *BloomFilterValidator) indexesFor(word string) []uint64 {
+	ha := fnv.New64a()
+	ha.Write([]byte(word)) //nolint:errcheck // fnv.Write never returns an error.
+	a := ha.Sum64()
+
+	hb := fnv.New64()
+	hb.Write([]byte(word)) //nolint:errcheck
+	b := hb.Sum64()
+
+	indexes := make([]uint64, v.hashes)
+	for i := 0; i < v.hashes; i++ {
+		indexes[i] = (a + uint64(i)*b) % v.size
+	}
+
+	return indexes
+}
+
+// bloomParameters computes the optimal bit array size and hash function count for a Bloom filter sized to
+// hold n entries at the given false positive rate p.
+func bloomParameters(n int, p float64) (size uint64, hashes int) {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round((m / float64(n)) * math.Ln2)
+
+	if k < 1 {
+		k = 1
+	}
+
+	return uint64(m), int(k)
+}
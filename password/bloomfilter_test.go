@@ -0,0 +1,49 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilterValidatorRejectsSeededWords(t *testing.T) {
+	words := strings.NewReader("password123\nletmein\nqwerty123\n")
+
+	validator, err := password.NewBloomFilterValidator(words, 3, 0.01)
+	assert.Nil(t, err)
+
+	assert.True(t, errors.Is(validator.Validate("password123"), password.ErrPasswordBreached))
+	assert.Nil(t, validator.Validate("a-password-not-in-the-list"))
+}
+
+// Test that NewBloomFilterValidator rejects degenerate sizing parameters with a typed error instead of
+// panicking.
+func TestNewBloomFilterValidatorInvalidParameters(t *testing.T) {
+	_, err := password.NewBloomFilterValidator(strings.NewReader(""), 0, 0.01)
+	assert.Equal(t, password.ErrInvalidBloomFilterParameters, err)
+
+	_, err = password.NewBloomFilterValidator(strings.NewReader(""), 3, 0)
+	assert.Equal(t, password.ErrInvalidBloomFilterParameters, err)
+
+	_, err = password.NewBloomFilterValidator(strings.NewReader(""), 3, 1)
+	assert.Equal(t, password.ErrInvalidBloomFilterParameters, err)
+}
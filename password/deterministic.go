@@ -0,0 +1,158 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrDeterministicPasswordRejected is returned by DeterministicGenerator.Derive when the derived password
+// fails its Rule. Unlike Generator, there is no internal retry budget: the same (masterSecret, siteLabel,
+// counter) tuple always derives the same password, so the only way to get a different one is for the
+// caller to retry with an incremented counter.
+var ErrDeterministicPasswordRejected = errors.New("password: derived password was rejected by its rule")
+
+// Argon2Parameters tunes the cost of the Argon2id derivation DeterministicGenerator runs for every
+// password it derives.
+type Argon2Parameters struct {
+	// Time is the number of passes Argon2id makes over memory.
+	Time uint32
+	// MemoryKiB is the amount of memory, in KiB, Argon2id uses during derivation.
+	MemoryKiB uint32
+	// Parallelism is the number of parallel lanes Argon2id uses during derivation.
+	Parallelism uint8
+}
+
+// DefaultArgon2Parameters are the Argon2id parameters DeterministicGenerator uses unless a caller supplies
+// its own; they follow the OWASP-recommended minimum for an interactive, password-hashing workload.
+var DefaultArgon2Parameters = Argon2Parameters{Time: 1, MemoryKiB: 64 * 1024, Parallelism: 4}
+
+/* DeterministicGenerator derives a password entirely from a (masterSecret, siteLabel, counter) tuple,
+ * using Argon2id in place of crypto/rand, so the exact same password can always be re-derived from a
+ * memorized master secret without ever being stored anywhere (the LessPass/gopass "stateless password
+ * manager" model; siteLabel is typically a domain plus username, and counter lets a compromised password
+ * be rotated without changing the master secret).
+ *
+ * It wraps an ordinary Rule and reads the same Configuration a Generator would, so the resulting password
+ * honors the rule's Length and every CharacterClassConfiguration Minimum. Unlike NewDeterministicGenerator,
+ * which reuses Generator's math/rand-driven construction seeded from an HMAC digest, DeterministicGenerator
+ * consumes its Argon2id output directly as a big-integer keystream: every character selection and the
+ * final Fisher-Yates shuffle peel successive digits off that keystream in the relevant base, so the whole
+ * derivation - including its cost parameters - is fixed by the caller rather than inherited from
+ * math/rand's algorithm.
+ */
+type DeterministicGenerator struct {
+	rule   Rule
+	params Argon2Parameters
+}
+
+// NewArgon2DeterministicGenerator constructs a DeterministicGenerator from rule, using params to tune the
+// cost of the underlying Argon2id derivation.
+func NewArgon2DeterministicGenerator(rule Rule, params Argon2Parameters) DeterministicGenerator {
+	return DeterministicGenerator{rule: rule, params: params}
+}
+
+// Derive returns the password for the given masterSecret, siteLabel and counter. The same three inputs
+// always derive the same password; a different siteLabel or counter derives an unrelated one.
+func (g DeterministicGenerator) Derive(masterSecret, siteLabel []byte, counter uint64) (string, error) {
+	config := g.rule.Config()
+
+	if err := config.validate(); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, len(siteLabel)+8)
+	copy(salt, siteLabel)
+	binary.BigEndian.PutUint64(salt[len(siteLabel):], counter)
+
+	keystream := argon2.IDKey(
+		masterSecret, salt, g.params.Time, g.params.MemoryKiB, g.params.Parallelism, keystreamLength(config))
+
+	digits := new(big.Int).SetBytes(keystream)
+
+	// When a MinLength/MaxLength range is configured, the length itself is peeled off the keystream first,
+	// so it's as deterministic as every character selection that follows it.
+	length := config.Length
+	if config.hasLengthRange() {
+		length = config.MinLength + nextBaseDigit(digits, config.MaxLength-config.MinLength+1)
+	}
+
+	candidate := make([]rune, 0, length)
+
+	allCharacters := make([]rune, 0)
+	for _, characterClass := range config.CharacterClasses {
+		characters := []rune(characterClass.Characters)
+		allCharacters = append(allCharacters, characters...)
+
+		for i := 0; i < characterClass.Minimum; i++ {
+			candidate = append(candidate, characters[nextBaseDigit(digits, len(characters))])
+		}
+	}
+
+	for len(candidate) < length {
+		candidate = append(candidate, allCharacters[nextBaseDigit(digits, len(allCharacters))])
+	}
+
+	shuffleWithKeystream(candidate, digits)
+
+	if !g.rule.Valid(candidate) {
+		return "", ErrDeterministicPasswordRejected
+	}
+
+	return string(candidate), nil
+}
+
+// keystreamLength sizes the Argon2id output so there's comfortably enough entropy left, even after every
+// character selection, to drive an unbiased Fisher-Yates shuffle of the final password. It sizes for the
+// longest password config can produce, so a MinLength/MaxLength range doesn't risk exhausting the
+// keystream at the top of its range.
+func keystreamLength(config *Configuration) uint32 {
+	const baseOverheadBytes = 64
+
+	length := config.Length
+	if config.hasLengthRange() {
+		length = config.MaxLength
+	}
+
+	return baseOverheadBytes + uint32(length)*8
+}
+
+// nextBaseDigit peels the next base-ary digit off digits via big-integer base conversion, consuming it
+// (digits is mutated in place) so the next call returns an independent value.
+func nextBaseDigit(digits *big.Int, base int) int {
+	if base <= 1 {
+		return 0
+	}
+
+	remainder := new(big.Int)
+	digits.DivMod(digits, big.NewInt(int64(base)), remainder)
+
+	return int(remainder.Int64())
+}
+
+// shuffleWithKeystream randomly permutes password in place using a Fisher-Yates shuffle driven by digits,
+// the same keystream used to select its characters, rather than by math/rand.
+func shuffleWithKeystream(password []rune, digits *big.Int) {
+	for i := len(password) - 1; i > 0; i-- {
+		j := nextBaseDigit(digits, i+1)
+		password[i], password[j] = password[j], password[i]
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fast Argon2id parameters so the test suite doesn't pay the OWASP-recommended cost on every run.
+func testArgon2Parameters() password.Argon2Parameters {
+	return password.Argon2Parameters{Time: 1, MemoryKiB: 8 * 1024, Parallelism: 1}
+}
+
+// Test that Derive reproduces the exact same password for the same inputs, and a different one for a
+// different siteLabel or counter.
+func TestDeterministicGeneratorDerive(t *testing.T) {
+	generator := password.NewArgon2DeterministicGenerator(newDummyPasswordRule(), testArgon2Parameters())
+	masterSecret := []byte("correct horse battery staple")
+
+	first, err := generator.Derive(masterSecret, []byte("example.com:alice"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(first))
+
+	second, err := generator.Derive(masterSecret, []byte("example.com:alice"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+
+	differentSite, err := generator.Derive(masterSecret, []byte("example.org:alice"), 0)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, differentSite)
+
+	rotated, err := generator.Derive(masterSecret, []byte("example.com:alice"), 1)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, rotated)
+}
+
+// Test that Derive honors the Minimum counts configured on every CharacterClassConfiguration.
+func TestDeterministicGeneratorHonorsMinimums(t *testing.T) {
+	generator := password.NewArgon2DeterministicGenerator(newDummyPasswordRule(), testArgon2Parameters())
+
+	for counter := uint64(0); counter < 25; counter++ {
+		derived, err := generator.Derive([]byte("a master secret"), []byte("example.com:bob"), counter)
+		assert.Nil(t, err)
+
+		letters, digits, special := 0, 0, 0
+
+		for _, c := range derived {
+			switch {
+			case contains(password.LowercaseCharacters+password.UppercaseCharacters, c):
+				letters++
+			case contains(password.DigitCharacters, c):
+				digits++
+			case contains(password.URLSafeSpecialCharacters, c):
+				special++
+			}
+		}
+
+		assert.True(t, letters >= 3)
+		assert.True(t, digits >= 3)
+		assert.True(t, special >= 1)
+	}
+}
+
+// Test that a rejecting Rule surfaces ErrDeterministicPasswordRejected, with no internal retry.
+func TestDeterministicGeneratorReturnsErrorForRejectedPassword(t *testing.T) {
+	generator := password.NewArgon2DeterministicGenerator(newBrokenPasswordRule(), testArgon2Parameters())
+
+	_, err := generator.Derive([]byte("a master secret"), []byte("example.com:carol"), 0)
+	assert.Equal(t, password.ErrDeterministicPasswordRejected, err)
+}
+
+func contains(s string, r rune) bool {
+	for _, c := range s {
+		if c == r {
+			return true
+		}
+	}
+
+	return false
+}
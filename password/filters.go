@@ -0,0 +1,167 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import "strings"
+
+// AmbiguousCharacters are characters that are commonly confused with one another when read aloud,
+// transcribed by hand, or displayed in a font that doesn't distinguish them clearly: the letter O and the
+// digit 0, and the letter I, lowercase l, and the digit 1.
+const AmbiguousCharacters = "O0Il1"
+
+// HomoglyphCharacters are characters that are commonly rendered as near-identical glyphs across common
+// fonts, a superset of AmbiguousCharacters.
+const HomoglyphCharacters = "O0Il1|S5Z2B8G6"
+
+// Sequences of keys considered adjacent for the purposes of NoSequential: the three rows of a QWERTY
+// keyboard, plus ascending runs of letters and digits.
+var keyboardRows = []string{
+	LowercaseCharacters,
+	UppercaseCharacters,
+	DigitCharacters,
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// RuleFilter is a composable password predicate, with the same signature and "true means acceptable"
+// convention as Rule.Valid. CombineRules ANDs a set of RuleFilters together with a base Rule's Valid
+// method, so they can express additional rejection criteria (eg. "no ambiguous characters") without every
+// Rule having to reimplement them.
+type RuleFilter func(password []rune) bool
+
+// ExcludeAmbiguous rejects any password containing a character from AmbiguousCharacters.
+func ExcludeAmbiguous() RuleFilter {
+	return ExcludeSimilarLooking(AmbiguousCharacters)
+}
+
+// ExcludeHomoglyphs rejects any password containing a character from HomoglyphCharacters.
+func ExcludeHomoglyphs() RuleFilter {
+	return ExcludeSimilarLooking(HomoglyphCharacters)
+}
+
+// ExcludeSimilarLooking rejects any password containing a character from set, the general form underlying
+// ExcludeAmbiguous and ExcludeHomoglyphs for callers with their own notion of which characters are too
+// similar to coexist in a single password.
+func ExcludeSimilarLooking(set string) RuleFilter {
+	excluded := make(map[rune]bool, len(set))
+	for _, r := range set {
+		excluded[r] = true
+	}
+
+	return func(password []rune) bool {
+		for _, r := range password {
+			if excluded[r] {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// NoRepeats rejects any password containing a run of n or more identical consecutive characters
+// (eg. NoRepeats(3) rejects "aaa").
+func NoRepeats(n int) RuleFilter {
+	return func(password []rune) bool {
+		run := 1
+
+		for i := 1; i < len(password); i++ {
+			if password[i] != password[i-1] {
+				run = 1
+				continue
+			}
+
+			run++
+			if run >= n {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// NoSequential rejects any password containing a run of n or more consecutive characters that are
+// adjacent, in either direction, in one of the keyboardRows sequences (eg. NoSequential(4) rejects
+// "abcd", "4321" and "qwer").
+func NoSequential(n int) RuleFilter {
+	return func(password []rune) bool {
+		for i := 0; i+n <= len(password); i++ {
+			if isSequentialRun(password[i : i+n]) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// isSequentialRun reports whether run appears, contiguously and in either direction, within any of the
+// keyboardRows sequences.
+func isSequentialRun(run []rune) bool {
+	forward := string(run)
+
+	reversed := make([]rune, len(run))
+	for i, r := range run {
+		reversed[len(run)-1-i] = r
+	}
+
+	backward := string(reversed)
+
+	for _, row := range keyboardRows {
+		if strings.Contains(row, forward) || strings.Contains(row, backward) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CombineRules wraps baseRule so that a candidate password must satisfy baseRule.Valid as well as every
+// supplied RuleFilter, eg.:
+//
+//	password.CombineRules(myRule, password.ExcludeAmbiguous(), password.NoSequential(4))
+func CombineRules(baseRule Rule, filters ...RuleFilter) Rule {
+	return &combinedRule{baseRule: baseRule, filters: filters}
+}
+
+// combinedRule is the Rule returned by CombineRules.
+type combinedRule struct {
+	baseRule Rule
+	filters  []RuleFilter
+}
+
+// Config delegates to the wrapped baseRule; RuleFilters only constrain Valid, not Config.
+func (r *combinedRule) Config() *Configuration {
+	return r.baseRule.Config()
+}
+
+// Valid reports whether password satisfies both the wrapped baseRule and every RuleFilter.
+func (r *combinedRule) Valid(password []rune) bool {
+	if !r.baseRule.Valid(password) {
+		return false
+	}
+
+	for _, filter := range r.filters {
+		if !filter(password) {
+			return false
+		}
+	}
+
+	return true
+}
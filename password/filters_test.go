@@ -0,0 +1,94 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that ExcludeAmbiguous accepts a password with none of AmbiguousCharacters, and rejects one with any.
+func TestExcludeAmbiguous(t *testing.T) {
+	filter := password.ExcludeAmbiguous()
+
+	assert.True(t, filter([]rune("correcthorse")))
+	assert.False(t, filter([]rune("c0rrecthorse")))
+	assert.False(t, filter([]rune("correctlhorse")))
+}
+
+// Test that ExcludeHomoglyphs rejects a wider set of similar-looking characters than ExcludeAmbiguous.
+func TestExcludeHomoglyphs(t *testing.T) {
+	filter := password.ExcludeHomoglyphs()
+
+	assert.True(t, filter([]rune("correcthorse")))
+	assert.False(t, filter([]rune("correcth0rse")))
+	assert.False(t, filter([]rune("correcth5rse")))
+}
+
+// Test that ExcludeSimilarLooking rejects exactly the characters in a caller-supplied set.
+func TestExcludeSimilarLooking(t *testing.T) {
+	filter := password.ExcludeSimilarLooking("xyz")
+
+	assert.True(t, filter([]rune("correcthorse")))
+	assert.False(t, filter([]rune("correcthorsex")))
+}
+
+// Test that NoRepeats accepts passwords with runs shorter than n, and rejects a run of n or more identical
+// consecutive characters.
+func TestNoRepeats(t *testing.T) {
+	filter := password.NoRepeats(3)
+
+	assert.True(t, filter([]rune("aabbcc")))
+	assert.False(t, filter([]rune("aaabbcc")))
+}
+
+// Test that NoSequential rejects runs of n or more consecutive characters from an alphabetic, digit or
+// keyboard-row sequence, in either direction, but accepts passwords without such a run.
+func TestNoSequential(t *testing.T) {
+	filter := password.NoSequential(4)
+
+	assert.True(t, filter([]rune("correcthorse")))
+	assert.False(t, filter([]rune("passabcdword")))
+	assert.False(t, filter([]rune("password4321")))
+	assert.False(t, filter([]rune("passqwerword")))
+	assert.False(t, filter([]rune("passrewqword")))
+}
+
+// Test that CombineRules ANDs a base Rule's Valid method together with every supplied RuleFilter.
+func TestCombineRules(t *testing.T) {
+	rule := password.CombineRules(newDummyPasswordRule(), password.ExcludeAmbiguous())
+
+	assert.True(t, rule.Valid([]rune("correcthorse")))
+	assert.False(t, rule.Valid([]rune("c0rrecthorse")))
+}
+
+// Test that CombineRules still rejects passwords the wrapped base Rule itself rejects.
+func TestCombineRulesDeferToBaseRule(t *testing.T) {
+	rule := password.CombineRules(newBrokenPasswordRule(), password.ExcludeAmbiguous())
+
+	assert.False(t, rule.Valid([]rune("correcthorse")))
+}
+
+// Test that CombineRules' Config delegates to the wrapped base Rule.
+func TestCombineRulesConfig(t *testing.T) {
+	base := newDummyPasswordRule()
+	rule := password.CombineRules(base, password.ExcludeAmbiguous())
+
+	assert.Equal(t, base.Config(), rule.Config())
+}
@@ -19,7 +19,11 @@
 package password
 
 import (
+	"crypto/hmac"
+	"crypto/sha512"
 	"errors"
+	"math"
+	mrand "math/rand"
 
 	"github.com/kloeckner-i/can-haz-password/rand"
 )
@@ -37,16 +41,106 @@ const (
 // ErrInvalidPasswordRejection is returned when a password rule rejects an excessive number of passwords.
 var ErrInvalidPasswordRejection = errors.New("password rule rejected too many passwords")
 
+// ErrFixedAndRangeLength is returned when a Configuration sets both Length and one of MinLength/MaxLength;
+// the two ways of specifying length are mutually exclusive.
+var ErrFixedAndRangeLength = errors.New("password: Length is mutually exclusive with MinLength/MaxLength")
+
+// ErrInvalidLengthRange is returned when a Configuration sets MinLength/MaxLength but they don't describe
+// a valid, non-empty range (both must be positive, and MaxLength must not be less than MinLength).
+var ErrInvalidLengthRange = errors.New("password: MinLength/MaxLength do not describe a valid range")
+
+// ErrMinimumsExceedLength is returned when the sum of a Configuration's CharacterClassConfiguration.Minimum
+// values is greater than the shortest password the configuration can produce, making it impossible to
+// satisfy every class's minimum within the target length.
+var ErrMinimumsExceedLength = errors.New("password: character class minimums exceed the configured length")
+
+// ErrMinimumsTooHigh is the error value returned by Configuration.MinimumsWarning when the sum of a
+// Configuration's CharacterClassConfiguration.Minimum values is more than half of the shortest password the
+// configuration can produce. This is non-fatal -- Generate and Derive succeed regardless -- but it's a
+// signal that minimum-heavy passwords leave little room for a Rule or Validator to reject on unrelated
+// grounds without exhausting the generator's rejection budget.
+var ErrMinimumsTooHigh = errors.New("password: character class minimums exceed half of the configured length")
+
 // Configuration sets the properties of the generated password.
 type Configuration struct {
-	/* Minimum length of the password.
-	 * The actual length will be random, and in the range minimum_length <= length <= 1.5 * minimum_length.
-	 * Random lengths allow for minimum complexity requirements to be met while not enforcing a strict
-	 * composition (eg. exactly 2 digits, and exactly 1 special character).
-	 */
+	// Length of the generated password, unless MinimumEntropyBits requires it to be longer. Mutually
+	// exclusive with MinLength/MaxLength; leave this zero when using a length range.
 	Length int
+	/* MinLength and MaxLength, when both set, generate a password of a uniform random length in
+	 * [MinLength, MaxLength] instead of the fixed Length. Mutually exclusive with Length.
+	 */
+	MinLength int
+	MaxLength int
 	// CharacterClasses configuration, eg. the list of characters and the minimum quantity to include.
 	CharacterClasses []CharacterClassConfiguration
+	/* MinimumEntropyBits, when set, causes the generator to keep appending characters past Length until
+	 * the Shannon entropy of the password, computed over the effective character alphabet, meets or
+	 * exceeds this threshold. This lets a caller ask for eg. "at least 80 bits" rather than guessing a
+	 * sufficient Length.
+	 */
+	MinimumEntropyBits float64
+	// Algorithm selects how characters are assembled into the password. Defaults to AlgorithmRandom.
+	Algorithm Algorithm
+}
+
+// hasLengthRange reports whether c specifies a MinLength/MaxLength range rather than a fixed Length.
+func (c *Configuration) hasLengthRange() bool {
+	return c.MinLength != 0 || c.MaxLength != 0
+}
+
+// shortestLength returns the shortest password c can produce: Length itself when fixed, or MinLength when
+// a range is configured. It's used to validate CharacterClassConfiguration minimums against the worst case.
+func (c *Configuration) shortestLength() int {
+	if c.hasLengthRange() {
+		return c.MinLength
+	}
+
+	return c.Length
+}
+
+// validate checks that c's length settings and CharacterClassConfiguration minimums are internally
+// consistent, returning a typed error describing the first problem found rather than letting the generator
+// loop indefinitely trying to satisfy an impossible configuration. It only fails closed on configurations
+// Generate/Derive can't possibly satisfy; see MinimumsWarning for the non-fatal "minimums are high but
+// satisfiable" case.
+func (c *Configuration) validate() error {
+	if c.Length != 0 && c.hasLengthRange() {
+		return ErrFixedAndRangeLength
+	}
+
+	if c.hasLengthRange() && (c.MinLength <= 0 || c.MaxLength <= 0 || c.MaxLength < c.MinLength) {
+		return ErrInvalidLengthRange
+	}
+
+	if c.minimumsSum() > c.shortestLength() {
+		return ErrMinimumsExceedLength
+	}
+
+	return nil
+}
+
+// minimumsSum returns the sum of c.CharacterClasses' Minimum values.
+func (c *Configuration) minimumsSum() int {
+	minimumsSum := 0
+	for _, characterClass := range c.CharacterClasses {
+		minimumsSum += characterClass.Minimum
+	}
+
+	return minimumsSum
+}
+
+// MinimumsWarning returns ErrMinimumsTooHigh if the sum of c's CharacterClassConfiguration minimums is more
+// than half of the shortest password c can produce, or nil otherwise. Unlike validate, this is purely
+// advisory: it doesn't block Generate or Derive, which succeed either way. Callers that want to surface the
+// regime to an operator or security reviewer can check it explicitly.
+func (c *Configuration) MinimumsWarning() error {
+	shortestLength := c.shortestLength()
+
+	if shortestLength > 0 && float64(c.minimumsSum()) > float64(shortestLength)*0.5 {
+		return ErrMinimumsTooHigh
+	}
+
+	return nil
 }
 
 // CharacterClassConfiguration configures the character composition of the password.
@@ -67,72 +161,280 @@ type Rule interface {
 
 // Generator generates random passwords matching a rule.
 type Generator struct {
-	characterSource rand.WeightedRandomSet
-	passwordRule    Rule
+	characterSource rand.RandomSet
+	// randSource drives the minimum-class sampling and the final Fisher-Yates shuffle. It is the same
+	// source backing characterSource, so that a deterministic Generator (see NewDeterministicGenerator)
+	// remains fully deterministic end to end.
+	randSource   *mrand.Rand
+	passwordRule Rule
+	// validators are run, in order, against every candidate password that satisfies passwordRule.
+	validators []Validator
 }
 
 // NewGenerator constructs a random password generator from a rule.
 func NewGenerator(passwordRule Rule) Generator {
+	randSource := rand.NewCryptoRand()
+
+	return Generator{
+		characterSource: buildCharacterSourceFromSource(passwordRule.Config(), randSource),
+		randSource:      randSource,
+		passwordRule:    passwordRule,
+	}
+}
+
+// NewGeneratorWithValidators constructs a random password generator from a rule, additionally running
+// every candidate password through the given chain of Validators (eg. HIBPValidator) before accepting it.
+func NewGeneratorWithValidators(passwordRule Rule, validators ...Validator) Generator {
+	randSource := rand.NewCryptoRand()
+
+	return Generator{
+		characterSource: buildCharacterSourceFromSource(passwordRule.Config(), randSource),
+		randSource:      randSource,
+		passwordRule:    passwordRule,
+		validators:      validators,
+	}
+}
+
+/* NewDeterministicGenerator constructs a Generator whose entire output is deterministically derived from
+ * masterSecret and siteContext, rather than from crypto/rand. Given the same masterSecret, siteContext
+ * and Rule, it reproduces the exact same password on every call, across processes and platforms.
+ *
+ * This enables a "stateless password manager" use case: a password is re-derived on demand from a
+ * memorized master secret and a value identifying the site/account it's for (eg. a domain plus a
+ * username), rather than ever being stored.
+ *
+ * masterSecret and siteContext are combined with HMAC-SHA512 into a seed for rand.NewSeededSource; no
+ * Validators can be attached, since a deterministic password can never be regenerated in response to a
+ * validator rejecting it.
+ */
+func NewDeterministicGenerator(passwordRule Rule, masterSecret, siteContext []byte) Generator {
+	mac := hmac.New(sha512.New, masterSecret)
+	mac.Write(siteContext)
+
+	randSource := rand.NewSeededSource(mac.Sum(nil))
+
 	return Generator{
-		characterSource: buildCharacterSource(passwordRule.Config()),
+		characterSource: buildCharacterSourceFromSource(passwordRule.Config(), randSource),
+		randSource:      randSource,
 		passwordRule:    passwordRule,
 	}
 }
 
 // Generate a new random password.
 func (g Generator) Generate() (string, error) {
+	if g.passwordRule.Config().Algorithm == AlgorithmPronounceable {
+		password, _, err := g.GeneratePronounceable()
+		return password, err
+	}
+
 	// Prevent the possibility of ending up in an infinite loop due to a bad rule.
 	const maxInvalidPasswordRejections = 10
 
 	config := g.passwordRule.Config()
-	password := make([]rune, 0)
 
-	for invalidPasswordRejections := 0; invalidPasswordRejections < maxInvalidPasswordRejections; {
-		if g.complete(config, password) {
-			return string(password), nil
-		}
+	targetLength, err := g.targetLength(config)
+	if err != nil {
+		return "", err
+	}
 
-		// Append the next character to the password.
-		password = append(password, g.characterSource.Next())
+	var lastValidatorErr error
 
-		// Reject the creation of passwords that the rule would consider "invalid".
-		if !g.passwordRule.Valid(password) {
-			// Rollback the last character.
-			password = password[:len(password)-1]
-			invalidPasswordRejections++
+	for invalidPasswordRejections := 0; invalidPasswordRejections < maxInvalidPasswordRejections; invalidPasswordRejections++ {
+		password := g.construct(config, targetLength)
 
+		// Reject the whole candidate if the rule considers it "invalid". Unlike the incremental,
+		// character-by-character construction this replaced, a rejection here simply means starting over;
+		// there's no partially built password to roll back.
+		if !g.passwordRule.Valid(password) {
 			continue
 		}
 
-		// If we exceed the maximum length, just try again from the start.
-		// This sets an upper bound on the long tail of the distribution.
-		if len(password) > int(float64(config.Length)*1.5) {
-			password = make([]rune, 0)
+		if err := runValidators(g.validators, string(password)); err != nil {
+			// The validator chain rejected this password (eg. it's been breached). Start again, using
+			// the same rejection budget as an invalid-per-Rule password.
+			lastValidatorErr = err
+
+			continue
 		}
+
+		return string(password), nil
+	}
+
+	if lastValidatorErr != nil {
+		return "", lastValidatorErr
 	}
 
 	return "", ErrInvalidPasswordRejection
 }
 
-// Have we completed the password? Eg. have we met the minimum length requirement and all of the complexity
-// requirements?
-func (g Generator) complete(config *Configuration, password []rune) bool {
-	if len(password) < config.Length {
-		return false
+// GenerateWithEntropy generates a new random password, exactly like Generate, and additionally returns the
+// actual Shannon entropy, in bits, of the generated password. This can differ from Entropy's estimate when
+// a MinLength/MaxLength range is configured, since Entropy reports the shortest-case figure but a given
+// password may have been generated longer.
+func (g Generator) GenerateWithEntropy() (string, float64, error) {
+	generated, err := g.Generate()
+	if err != nil {
+		return "", 0, err
 	}
 
+	return generated, g.characterSource.Entropy() * float64(len([]rune(generated))), nil
+}
+
+/* construct builds a single candidate password of exactly targetLength runes: it draws exactly
+ * Minimum characters from every CharacterClassConfiguration up front (guaranteeing the complexity
+ * requirements are met without ever discarding a partially built password), fills the remainder from
+ * the weighted character source, then shuffles the result with a crypto-backed Fisher-Yates shuffle so
+ * the guaranteed-minimum characters aren't predictably front-loaded.
+ */
+func (g Generator) construct(config *Configuration, targetLength int) []rune {
+	password := make([]rune, 0, targetLength)
+
 	for _, characterClass := range config.CharacterClasses {
-		if occurrencesOfCharacters(password, []rune(characterClass.Characters)) < characterClass.Minimum {
-			return false
+		password = append(password, g.sampleMinimum(characterClass)...)
+	}
+
+	for len(password) < targetLength {
+		password = append(password, g.characterSource.Next())
+	}
+
+	g.shuffle(password)
+
+	return password
+}
+
+// sampleMinimum draws exactly characterClass.Minimum characters, uniformly at random, from
+// characterClass.Characters.
+func (g Generator) sampleMinimum(characterClass CharacterClassConfiguration) []rune {
+	characters := []rune(characterClass.Characters)
+	sampled := make([]rune, characterClass.Minimum)
+
+	for i := range sampled {
+		sampled[i] = characters[g.randSource.Intn(len(characters))]
+	}
+
+	return sampled
+}
+
+// shuffle randomly permutes password in place using a Fisher-Yates shuffle. g.randSource.Intn performs
+// rejection sampling internally, so the result is free of modulo bias.
+func (g Generator) shuffle(password []rune) {
+	for i := len(password) - 1; i > 0; i-- {
+		j := g.randSource.Intn(i + 1)
+		password[i], password[j] = password[j], password[i]
+	}
+}
+
+// Entropy estimates the Shannon entropy, in bits, of passwords produced by this Generator. See the
+// package-level Entropy for details.
+func (g Generator) Entropy() float64 {
+	return Entropy(g.passwordRule.Config())
+}
+
+// Entropy estimates the Shannon entropy, in bits, of passwords produced for config at its configured
+// Length, or at its shortest possible length when a MinLength/MaxLength range is configured. Characters
+// are treated as drawn independently according to config's character class weights, which is a close
+// approximation given the low rejection rate of typical rules.
+func Entropy(config *Configuration) float64 {
+	characterSource := buildCharacterSourceFromSource(config, rand.NewCryptoRand())
+
+	return characterSource.Entropy() * float64(config.shortestLength())
+}
+
+// Strength classifies an estimated entropy figure, in bits, into a coarse Weak/Fair/Strong/VeryStrong
+// rating. The thresholds are the widely used rule-of-thumb bands: below 40 bits is within reach of a
+// determined offline attacker, 40-59 bits is adequate against casual attacks, 60-79 bits is solid for most
+// purposes, and 80+ bits is the Diceware-style "very strong" target.
+type Strength int
+
+const (
+	// Weak marks passwords estimated below fairEntropyBits.
+	Weak Strength = iota
+	// Fair marks passwords estimated at or above fairEntropyBits but below strongEntropyBits.
+	Fair
+	// Strong marks passwords estimated at or above strongEntropyBits but below veryStrongEntropyBits.
+	Strong
+	// VeryStrong marks passwords estimated at or above veryStrongEntropyBits.
+	VeryStrong
+)
+
+// String returns the human-readable name of s.
+func (s Strength) String() string {
+	switch s {
+	case Weak:
+		return "Weak"
+	case Fair:
+		return "Fair"
+	case Strong:
+		return "Strong"
+	case VeryStrong:
+		return "VeryStrong"
+	default:
+		return "Unknown"
+	}
+}
+
+// Entropy thresholds, in bits, used by Strength classification. See Strength for the rationale behind
+// each band.
+const (
+	fairEntropyBits       = 40.0
+	strongEntropyBits     = 60.0
+	veryStrongEntropyBits = 80.0
+)
+
+// Strength classifies g's estimated entropy (see Generator.Entropy) into a Weak/Fair/Strong/VeryStrong
+// rating.
+func (g Generator) Strength() Strength {
+	return classifyStrength(g.Entropy())
+}
+
+// classifyStrength buckets an entropy figure, in bits, into a Strength rating.
+func classifyStrength(entropyBits float64) Strength {
+	switch {
+	case entropyBits >= veryStrongEntropyBits:
+		return VeryStrong
+	case entropyBits >= strongEntropyBits:
+		return Strong
+	case entropyBits >= fairEntropyBits:
+		return Fair
+	default:
+		return Weak
+	}
+}
+
+// targetLength returns the password length to construct for a single candidate: config.Length, or a
+// uniform random length in [MinLength, MaxLength] drawn from g.randSource when a range is configured,
+// extended further if MinimumEntropyBits demands more characters to reach the desired entropy, given the
+// per-character entropy of the generator's character source. It returns an error if config's length
+// settings or character class minimums are invalid.
+func (g Generator) targetLength(config *Configuration) (int, error) {
+	if err := config.validate(); err != nil {
+		return 0, err
+	}
+
+	length := config.Length
+	if config.hasLengthRange() {
+		length = config.MinLength + g.randSource.Intn(config.MaxLength-config.MinLength+1)
+	}
+
+	if config.MinimumEntropyBits <= 0.0 {
+		return length, nil
+	}
+
+	if perCharacterEntropy := g.characterSource.Entropy(); perCharacterEntropy > 0.0 {
+		if entropyLength := int(math.Ceil(config.MinimumEntropyBits / perCharacterEntropy)); entropyLength > length {
+			length = entropyLength
 		}
 	}
 
-	return true
+	return length, nil
 }
 
 // The character source is backed by a weighted random set that returns values according to a distribution
-// consistent with the desired composition of the final password.
-func buildCharacterSource(config *Configuration) rand.WeightedRandomSet {
+// consistent with the desired composition of the final password. It draws from randSource, which is
+// either a crypto/rand-backed source or, for a deterministic Generator, one seeded from a master secret.
+// rand.NewBestRandomSet picks the interval tree or alias method backend, whichever suits the character
+// pool's size.
+func buildCharacterSourceFromSource(config *Configuration, randSource *mrand.Rand) rand.RandomSet {
 	// The total number of characters in all of our character classes.
 	totalCount := 0
 	for _, characterClass := range config.CharacterClasses {
@@ -150,7 +452,7 @@ func buildCharacterSource(config *Configuration) rand.WeightedRandomSet {
 		entries = addCharactersToWeightedRandomSet(entries, []rune(characterClass.Characters), probability)
 	}
 
-	return rand.NewWeightedRandomSet(entries)
+	return rand.NewBestRandomSet(entries, randSource)
 }
 
 // If the character class is specified, calculate the desired probability of each character in the character class,
@@ -172,24 +474,3 @@ func addCharactersToWeightedRandomSet(
 
 	return entries
 }
-
-// Count the number of occurrences of the character class in a password.
-// Used for determining if a generated password meets the complexity rules.
-func occurrencesOfCharacters(password, characterClass []rune) int {
-	freq := make(map[rune]int)
-	total := 0
-
-	// Sum up the total number of occurrences of each character in the password.
-	for _, c := range password {
-		freq[c]++
-	}
-
-	// Go through all the characters contained in the character class and count the total number of occurrences.
-	for _, c := range characterClass {
-		if count, ok := freq[c]; ok {
-			total += count
-		}
-	}
-
-	return total
-}
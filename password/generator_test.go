@@ -39,30 +39,11 @@ func TestPasswordGenerator(t *testing.T) {
 		passwords = append(passwords, password)
 	}
 
-	// Compute statistics on the length of the generated passwords.
-	minLength := 12
-	maxLength := 0
-	meanLength := 0.0
-
+	// The two-phase construct-then-shuffle approach produces passwords of exactly Length runes.
 	for _, password := range passwords {
-		if len(password) < minLength {
-			minLength = len(password)
-		}
-
-		if len(password) > maxLength {
-			maxLength = len(password)
-		}
-
-		meanLength += float64(len(password))
+		assert.Equal(t, 8, len(password))
 	}
 
-	meanLength /= float64(len(passwords))
-
-	// Ensure the password length is bounded and the average length falls within the expected range.
-	assert.Equal(t, 8, minLength)
-	assert.Equal(t, 12, maxLength)
-	assert.True(t, meanLength > 9.0 && meanLength < 10.0)
-
 	// Is the distribution of characters as expected?
 	// Calculate this by summing up the total number of occurrences for each character.
 	total := 0
@@ -79,36 +60,35 @@ func TestPasswordGenerator(t *testing.T) {
 		counts[i] = count / float64(total)
 	}
 
+	// The rule guarantees 3 letters, 3 digits and 1 special character (7 of the 8), with the 8th drawn
+	// from the same 3:3:1 weighting; so the expected probability of any one character is its class's
+	// share of the 7 guaranteed characters, divided evenly among the characters in that class.
+	const totalMinimum = 7.0
+
 	allLetters := append([]rune(password.UppercaseCharacters), []rune(password.LowercaseCharacters)...)
-	// From the password rule we expect 3/8s of all characters to be a letter of either case.
-	expectedLetterProbability := 3.0 / (8.0 * float64(len(allLetters)))
+	expectedLetterProbability := 3.0 / (totalMinimum * float64(len(allLetters)))
 
 	for _, c := range allLetters {
 		assert.True(t, math.Abs(counts[c]-expectedLetterProbability) < 0.01)
 	}
 
 	digits := []rune(password.DigitCharacters)
-	// From the password rule we expect 3/8s of all characters to be a digit.
-	expectedDigitProbability := 3.0 / (8.0 * float64(len(digits)))
+	expectedDigitProbability := 3.0 / (totalMinimum * float64(len(digits)))
 
 	for _, c := range digits {
 		assert.True(t, math.Abs(counts[c]-expectedDigitProbability) < 0.01)
 	}
 
 	specialCharacters := []rune(password.URLSafeSpecialCharacters)
-	// From the password rule we expect 1/8 of all characters to be a special character.
-	expectedSpecialCharacterProbability := 1.0 / (8.0 * float64(len(specialCharacters)))
+	expectedSpecialCharacterProbability := 1.0 / (totalMinimum * float64(len(specialCharacters)))
 
 	for _, c := range specialCharacters {
 		fmt.Println(counts[c], expectedSpecialCharacterProbability)
-		// We allow a slightly larger error margin for special characters, as the combination of low normal
-		// prevalence, combined with the minimum complexity rule, leads to them being slightly overrepresented.
-		// To the order of 30 - 40% but this is an expected consequence of the minimum complexity rules.
 		assert.True(t, math.Abs(counts[c]-expectedSpecialCharacterProbability) < 0.025)
 	}
 
 	// Do the passwords contain any invalid characters? Eg. characters outside of the expected classes.
-	valid := regexp.MustCompile(`^[a-zA-Z0-9_-]{8,12}$`)
+	valid := regexp.MustCompile(`^[a-zA-Z0-9_-]{8}$`)
 	// Are any of the passwords "invalid" somehow?
 	invalid := regexp.MustCompile(`[-]{2,}`)
 
@@ -118,6 +98,168 @@ func TestPasswordGenerator(t *testing.T) {
 	}
 }
 
+// Test that Entropy reports the expected Shannon entropy for a rule's character distribution and Length.
+func TestPasswordGeneratorEntropy(t *testing.T) {
+	generator := password.NewGenerator(newDummyPasswordRule())
+
+	assert.InDelta(t, 43.7, generator.Entropy(), 0.5)
+}
+
+// Test that the package-level Entropy agrees with Generator.Entropy for the same Configuration, since
+// the latter is defined in terms of the former.
+func TestEntropy(t *testing.T) {
+	generator := password.NewGenerator(newDummyPasswordRule())
+
+	assert.Equal(t, generator.Entropy(), password.Entropy(newDummyPasswordRule().Config()))
+}
+
+// Test that Strength buckets a Generator's estimated entropy into the expected Weak/Fair/Strong/VeryStrong
+// rating.
+func TestPasswordGeneratorStrength(t *testing.T) {
+	rule := newDummyPasswordRule()
+	generator := password.NewGenerator(rule)
+
+	// newDummyPasswordRule's default Configuration estimates ~43.7 bits of entropy (see
+	// TestPasswordGeneratorEntropy), which falls in the Fair band.
+	assert.Equal(t, password.Fair, generator.Strength())
+
+	rule.minLength, rule.maxLength = 16, 16
+	assert.Equal(t, password.VeryStrong, password.NewGenerator(rule).Strength())
+}
+
+// Test that GenerateWithEntropy returns a password alongside its actual entropy, which tracks the
+// generated password's length even when it was grown past Config.Length to meet MinimumEntropyBits.
+func TestPasswordGeneratorGenerateWithEntropy(t *testing.T) {
+	rule := newDummyPasswordRule()
+	rule.entropyBits = 60.0
+	generator := password.NewGenerator(rule)
+
+	generated, bits, err := generator.GenerateWithEntropy()
+	assert.Nil(t, err)
+	assert.True(t, bits >= 60.0, "entropy %v of generated password did not meet the 60 bit floor", bits)
+
+	perCharacterEntropy := password.Entropy(rule.Config()) / 8.0
+	assert.InDelta(t, perCharacterEntropy*float64(len(generated)), bits, 0.01)
+}
+
+// Test that MinimumEntropyBits extends the password past its minimum Length when the Length alone
+// would not meet the desired entropy.
+func TestPasswordGeneratorMinimumEntropyBits(t *testing.T) {
+	rule := newDummyPasswordRule()
+	// This rule's character source works out to a little under 5.5 bits per character, so its Length
+	// of 8 alone (around 44 bits) falls well short of 60 bits; at least 11 characters are needed.
+	rule.entropyBits = 60.0
+	generator := password.NewGenerator(rule)
+
+	for i := 0; i < 1_000; i++ {
+		generated, err := generator.Generate()
+		assert.Nil(t, err)
+		assert.Truef(t, len(generated) >= 11, "password '%v' was too short to meet the entropy floor", generated)
+	}
+}
+
+// Test that a MinLength/MaxLength range produces passwords whose length varies across that range, and
+// that every generated password falls within it.
+func TestPasswordGeneratorLengthRange(t *testing.T) {
+	rule := newDummyPasswordRule()
+	rule.minLength, rule.maxLength = 10, 20
+	generator := password.NewGenerator(rule)
+
+	lengths := make(map[int]bool)
+
+	for i := 0; i < 1_000; i++ {
+		generated, err := generator.Generate()
+		assert.Nil(t, err)
+		assert.True(t, len(generated) >= 10 && len(generated) <= 20)
+
+		lengths[len(generated)] = true
+	}
+
+	// Over 1,000 draws from an 11-value range, every length should show up at least once.
+	assert.Equal(t, 11, len(lengths))
+}
+
+// Test that Generate rejects a Configuration that sets both Length and a MinLength/MaxLength range.
+func TestPasswordGeneratorErrFixedAndRangeLength(t *testing.T) {
+	rule := newDummyPasswordRule()
+	rule.minLength, rule.maxLength = 10, 20
+	rule.Config() // sanity: constructing the config alone doesn't validate it
+
+	generator := password.NewGenerator(rule)
+
+	// Force both Length and a range to be set, which newDummyPasswordRule's Config doesn't normally allow.
+	generator = password.NewGenerator(&fixedAndRangeLengthRule{})
+
+	_, err := generator.Generate()
+	assert.Equal(t, password.ErrFixedAndRangeLength, err)
+}
+
+// Test that Generate rejects a Configuration whose character class minimums sum to more than the shortest
+// password it can produce.
+func TestPasswordGeneratorErrMinimumsExceedLength(t *testing.T) {
+	rule := newDummyPasswordRule()
+	rule.minLength, rule.maxLength = 5, 10
+
+	generator := password.NewGenerator(rule)
+
+	_, err := generator.Generate()
+	assert.Equal(t, password.ErrMinimumsExceedLength, err)
+}
+
+// Test that a Configuration whose character class minimums sum to more than half of the shortest password
+// it can produce, without exceeding it outright, still generates successfully, but surfaces
+// ErrMinimumsTooHigh as a non-fatal warning via MinimumsWarning.
+func TestPasswordGeneratorMinimumsWarning(t *testing.T) {
+	rule := newDummyPasswordRule()
+	rule.minLength, rule.maxLength = 8, 10
+
+	generator := password.NewGenerator(rule)
+
+	_, err := generator.Generate()
+	assert.Nil(t, err)
+
+	assert.Equal(t, password.ErrMinimumsTooHigh, rule.Config().MinimumsWarning())
+}
+
+// fixedAndRangeLengthRule is a minimal Rule whose Configuration sets both Length and a MinLength/MaxLength
+// range, which newDummyPasswordRule's Config deliberately can't express.
+type fixedAndRangeLengthRule struct{}
+
+func (r *fixedAndRangeLengthRule) Config() *password.Configuration {
+	return &password.Configuration{
+		Length:    8,
+		MinLength: 4,
+		MaxLength: 8,
+		CharacterClasses: []password.CharacterClassConfiguration{
+			{Characters: password.LowercaseCharacters, Minimum: 1},
+		},
+	}
+}
+
+func (r *fixedAndRangeLengthRule) Valid(_ []rune) bool {
+	return true
+}
+
+// Test that NewDeterministicGenerator reproduces the exact same password for the same master secret and
+// site context, and a different one for a different site context.
+func TestDeterministicPasswordGenerator(t *testing.T) {
+	rule := newDummyPasswordRule()
+	masterSecret := []byte("correct horse battery staple")
+
+	first, err := password.NewDeterministicGenerator(rule, masterSecret, []byte("example.com:alice")).Generate()
+	assert.Nil(t, err)
+
+	second, err := password.NewDeterministicGenerator(rule, masterSecret, []byte("example.com:alice")).Generate()
+	assert.Nil(t, err)
+
+	assert.Equal(t, first, second)
+
+	third, err := password.NewDeterministicGenerator(rule, masterSecret, []byte("example.org:alice")).Generate()
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, first, third)
+}
+
 // Test that a broken password rule (eg. one that rejects every password) returns an error.
 func TestPasswordGeneratorReturnsErrorForBrokenRule(t *testing.T) {
 	generator := password.NewGenerator(newBrokenPasswordRule())
@@ -130,6 +272,11 @@ func TestPasswordGeneratorReturnsErrorForBrokenRule(t *testing.T) {
 // Short passwords with hybris style invalid characters.
 type dummyPasswordRule struct {
 	invalid *regexp.Regexp
+	// entropyBits, when set, is passed through to the rule's Configuration as MinimumEntropyBits.
+	entropyBits float64
+	// minLength and maxLength, when both set, are passed through as MinLength/MaxLength in place of the
+	// default fixed Length.
+	minLength, maxLength int
 }
 
 func newDummyPasswordRule() *dummyPasswordRule {
@@ -140,14 +287,23 @@ func newDummyPasswordRule() *dummyPasswordRule {
 }
 
 func (r *dummyPasswordRule) Config() *password.Configuration {
-	return &password.Configuration{
-		Length: 8,
+	config := &password.Configuration{
+		Length:             8,
+		MinimumEntropyBits: r.entropyBits,
 		CharacterClasses: []password.CharacterClassConfiguration{
 			{Characters: password.LowercaseCharacters + password.UppercaseCharacters, Minimum: 3},
 			{Characters: password.DigitCharacters, Minimum: 3},
 			{Characters: password.URLSafeSpecialCharacters, Minimum: 1},
 		},
 	}
+
+	if r.minLength != 0 || r.maxLength != 0 {
+		config.Length = 0
+		config.MinLength = r.minLength
+		config.MaxLength = r.maxLength
+	}
+
+	return config
 }
 
 func (r *dummyPasswordRule) Valid(password []rune) bool {
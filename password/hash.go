@@ -0,0 +1,518 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// HashScheme identifies one of the password hashing algorithms this package implements.
+type HashScheme string
+
+const (
+	HashSchemeBcrypt       HashScheme = "bcrypt"
+	HashSchemeScrypt       HashScheme = "scrypt"
+	HashSchemeArgon2id     HashScheme = "argon2id"
+	HashSchemePBKDF2SHA256 HashScheme = "pbkdf2-sha256"
+	HashSchemePBKDF2SHA512 HashScheme = "pbkdf2-sha512"
+)
+
+// saltLength is the size, in bytes, of the random salt generated for every scheme except bcrypt (which
+// generates its own as part of bcrypt.GenerateFromPassword).
+const saltLength = 16
+
+// ErrUnknownHashScheme is returned when an encoded hash names a scheme this package doesn't implement.
+var ErrUnknownHashScheme = errors.New("password: unknown hash scheme")
+
+// ErrMalformedHash is returned when an encoded hash string doesn't match the expected layout, or carries
+// parameters that can't be parsed, for its scheme.
+var ErrMalformedHash = errors.New("password: malformed encoded hash")
+
+// Hasher hashes and verifies passwords for a single scheme, producing and consuming PHC-style encoded
+// strings, eg. "$scrypt$n=32768,r=8,p=1,k=32$<salt>$<hash>" (Argon2id additionally carries its version as
+// its own field, per the canonical argon2 PHC layout: "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>"). A
+// Hasher's fields configure the cost of Hash; Verify instead reads the cost back out of the encoded string
+// it's given, so a password hashed under old parameters still verifies correctly after the defaults change.
+type Hasher interface {
+	// Scheme identifies which HashScheme this Hasher implements.
+	Scheme() HashScheme
+	// Hash returns a PHC-style encoded string for password, generating a fresh random salt.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded, a string previously returned by Hash.
+	Verify(encoded, password string) (bool, error)
+}
+
+// Hash hashes password with hasher, returning a PHC-style encoded string that records hasher's scheme and
+// parameters alongside the salt and hash, so a later Verify doesn't need to be told which Hasher or
+// parameters originally produced it.
+func Hash(password string, hasher Hasher) (string, error) {
+	return hasher.Hash(password)
+}
+
+// Verify reports whether password matches encoded, a PHC-style string previously returned by Hash. The
+// scheme is read directly out of encoded, so the caller doesn't need to know which Hasher produced it.
+func Verify(encoded, password string) (bool, error) {
+	switch schemeOf(encoded) {
+	case HashSchemeBcrypt:
+		return BcryptHasher{}.Verify(encoded, password)
+	case HashSchemeScrypt:
+		return ScryptHasher{}.Verify(encoded, password)
+	case HashSchemeArgon2id:
+		return Argon2idHasher{}.Verify(encoded, password)
+	case HashSchemePBKDF2SHA256:
+		return PBKDF2SHA256Hasher{}.Verify(encoded, password)
+	case HashSchemePBKDF2SHA512:
+		return PBKDF2SHA512Hasher{}.Verify(encoded, password)
+	default:
+		return false, ErrUnknownHashScheme
+	}
+}
+
+// schemeOf extracts the HashScheme an encoded hash string was produced with, without fully parsing it.
+func schemeOf(encoded string) HashScheme {
+	if strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$") {
+		return HashSchemeBcrypt
+	}
+
+	parts := strings.SplitN(encoded, "$", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return HashScheme(parts[1])
+}
+
+// BcryptHasher hashes passwords with bcrypt. Unlike the other schemes here, bcrypt produces its own
+// self-contained encoding ("$2a$<cost>$<salt><hash>"), so it is passed through unmodified rather than
+// wrapped in the generic PHC helpers below.
+type BcryptHasher struct {
+	// Cost is the bcrypt work factor. Defaults to bcrypt.DefaultCost if zero.
+	Cost int
+}
+
+// Scheme identifies this Hasher as HashSchemeBcrypt.
+func (h BcryptHasher) Scheme() HashScheme {
+	return HashSchemeBcrypt
+}
+
+// Hash returns a bcrypt-encoded hash of password.
+func (h BcryptHasher) Hash(password string) (string, error) {
+	cost := h.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashed), nil
+}
+
+// Verify reports whether password matches encoded, a bcrypt-encoded hash.
+func (h BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// ScryptHasher hashes passwords with scrypt.
+type ScryptHasher struct {
+	// N, R and P are the scrypt cost, block size and parallelization parameters; see scrypt.Key. Each
+	// defaults to a reasonable interactive-login cost (N=32768, R=8, P=1) if zero.
+	N, R, P int
+	// KeyLength is the length, in bytes, of the derived hash. Defaults to 32 if zero.
+	KeyLength int
+}
+
+// Scheme identifies this Hasher as HashSchemeScrypt.
+func (h ScryptHasher) Scheme() HashScheme {
+	return HashSchemeScrypt
+}
+
+// Hash returns a PHC-style scrypt hash of password.
+func (h ScryptHasher) Hash(password string) (string, error) {
+	n, r, p, keyLength := h.paramsOrDefault()
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hashed, err := scrypt.Key([]byte(password), salt, n, r, p, keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	params := fmt.Sprintf("n=%d,r=%d,p=%d,k=%d", n, r, p, keyLength)
+
+	return encodePHC(HashSchemeScrypt, params, salt, hashed), nil
+}
+
+// Verify reports whether password matches encoded, a PHC-style scrypt hash.
+func (h ScryptHasher) Verify(encoded, password string) (bool, error) {
+	scheme, params, salt, hashed, err := decodePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	if scheme != HashSchemeScrypt {
+		return false, ErrMalformedHash
+	}
+
+	values, err := parseParams(params)
+	if err != nil {
+		return false, err
+	}
+
+	n, errN := strconv.Atoi(values["n"])
+	r, errR := strconv.Atoi(values["r"])
+	p, errP := strconv.Atoi(values["p"])
+
+	if errN != nil || errR != nil || errP != nil {
+		return false, ErrMalformedHash
+	}
+
+	candidate, err := scrypt.Key([]byte(password), salt, n, r, p, len(hashed))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(candidate, hashed) == 1, nil
+}
+
+func (h ScryptHasher) paramsOrDefault() (n, r, p, keyLength int) {
+	n, r, p, keyLength = h.N, h.R, h.P, h.KeyLength
+
+	if n == 0 {
+		n = 32768
+	}
+
+	if r == 0 {
+		r = 8
+	}
+
+	if p == 0 {
+		p = 1
+	}
+
+	if keyLength == 0 {
+		keyLength = 32
+	}
+
+	return n, r, p, keyLength
+}
+
+// Argon2idHasher hashes passwords with Argon2id, the same KDF DeterministicGenerator uses for password
+// derivation.
+type Argon2idHasher struct {
+	// Params tunes the Argon2id cost. Defaults to DefaultArgon2Parameters if its zero value.
+	Params Argon2Parameters
+	// KeyLength is the length, in bytes, of the derived hash. Defaults to 32 if zero.
+	KeyLength uint32
+}
+
+// Scheme identifies this Hasher as HashSchemeArgon2id.
+func (h Argon2idHasher) Scheme() HashScheme {
+	return HashSchemeArgon2id
+}
+
+// Hash returns a PHC-style Argon2id hash of password.
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	params, keyLength := h.paramsOrDefault()
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hashed := argon2.IDKey([]byte(password), salt, params.Time, params.MemoryKiB, params.Parallelism, keyLength)
+
+	encodedParams := fmt.Sprintf("m=%d,t=%d,p=%d", params.MemoryKiB, params.Time, params.Parallelism)
+
+	return encodeArgon2idPHC(argon2.Version, encodedParams, salt, hashed), nil
+}
+
+// Verify reports whether password matches encoded, a PHC-style Argon2id hash.
+func (h Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	_, params, salt, hashed, err := decodeArgon2idPHC(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	values, err := parseParams(params)
+	if err != nil {
+		return false, err
+	}
+
+	memory, errM := strconv.Atoi(values["m"])
+	time, errT := strconv.Atoi(values["t"])
+	parallelism, errP := strconv.Atoi(values["p"])
+
+	if errM != nil || errT != nil || errP != nil {
+		return false, ErrMalformedHash
+	}
+
+	candidate := argon2.IDKey(
+		[]byte(password), salt, uint32(time), uint32(memory), uint8(parallelism), uint32(len(hashed)))
+
+	return subtle.ConstantTimeCompare(candidate, hashed) == 1, nil
+}
+
+func (h Argon2idHasher) paramsOrDefault() (Argon2Parameters, uint32) {
+	params := h.Params
+	if params == (Argon2Parameters{}) {
+		params = DefaultArgon2Parameters
+	}
+
+	keyLength := h.KeyLength
+	if keyLength == 0 {
+		keyLength = 32
+	}
+
+	return params, keyLength
+}
+
+// PBKDF2SHA256Hasher hashes passwords with PBKDF2-HMAC-SHA256.
+type PBKDF2SHA256Hasher struct {
+	// Iterations is the PBKDF2 iteration count. Defaults to 600,000, OWASP's current recommended minimum
+	// for PBKDF2-HMAC-SHA256, if zero.
+	Iterations int
+	// KeyLength is the length, in bytes, of the derived hash. Defaults to 32 if zero.
+	KeyLength int
+}
+
+// Scheme identifies this Hasher as HashSchemePBKDF2SHA256.
+func (h PBKDF2SHA256Hasher) Scheme() HashScheme {
+	return HashSchemePBKDF2SHA256
+}
+
+// Hash returns a PHC-style PBKDF2-HMAC-SHA256 hash of password.
+func (h PBKDF2SHA256Hasher) Hash(password string) (string, error) {
+	return pbkdf2Hash(HashSchemePBKDF2SHA256, password, h.iterationsOrDefault(), h.keyLengthOrDefault(), sha256.New)
+}
+
+// Verify reports whether password matches encoded, a PHC-style PBKDF2-HMAC-SHA256 hash.
+func (h PBKDF2SHA256Hasher) Verify(encoded, password string) (bool, error) {
+	return pbkdf2Verify(HashSchemePBKDF2SHA256, encoded, password, sha256.New)
+}
+
+func (h PBKDF2SHA256Hasher) iterationsOrDefault() int {
+	if h.Iterations == 0 {
+		return 600_000
+	}
+
+	return h.Iterations
+}
+
+func (h PBKDF2SHA256Hasher) keyLengthOrDefault() int {
+	if h.KeyLength == 0 {
+		return 32
+	}
+
+	return h.KeyLength
+}
+
+// PBKDF2SHA512Hasher hashes passwords with PBKDF2-HMAC-SHA512.
+type PBKDF2SHA512Hasher struct {
+	// Iterations is the PBKDF2 iteration count. Defaults to 210,000, OWASP's current recommended minimum
+	// for PBKDF2-HMAC-SHA512, if zero.
+	Iterations int
+	// KeyLength is the length, in bytes, of the derived hash. Defaults to 32 if zero.
+	KeyLength int
+}
+
+// Scheme identifies this Hasher as HashSchemePBKDF2SHA512.
+func (h PBKDF2SHA512Hasher) Scheme() HashScheme {
+	return HashSchemePBKDF2SHA512
+}
+
+// Hash returns a PHC-style PBKDF2-HMAC-SHA512 hash of password.
+func (h PBKDF2SHA512Hasher) Hash(password string) (string, error) {
+	return pbkdf2Hash(HashSchemePBKDF2SHA512, password, h.iterationsOrDefault(), h.keyLengthOrDefault(), sha512.New)
+}
+
+// Verify reports whether password matches encoded, a PHC-style PBKDF2-HMAC-SHA512 hash.
+func (h PBKDF2SHA512Hasher) Verify(encoded, password string) (bool, error) {
+	return pbkdf2Verify(HashSchemePBKDF2SHA512, encoded, password, sha512.New)
+}
+
+func (h PBKDF2SHA512Hasher) iterationsOrDefault() int {
+	if h.Iterations == 0 {
+		return 210_000
+	}
+
+	return h.Iterations
+}
+
+func (h PBKDF2SHA512Hasher) keyLengthOrDefault() int {
+	if h.KeyLength == 0 {
+		return 32
+	}
+
+	return h.KeyLength
+}
+
+// pbkdf2Hash is shared by PBKDF2SHA256Hasher and PBKDF2SHA512Hasher; they differ only in scheme name and
+// hash constructor.
+func pbkdf2Hash(scheme HashScheme, password string, iterations, keyLength int, newHash func() hash.Hash) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hashed := pbkdf2.Key([]byte(password), salt, iterations, keyLength, newHash)
+	params := fmt.Sprintf("i=%d,k=%d", iterations, keyLength)
+
+	return encodePHC(scheme, params, salt, hashed), nil
+}
+
+// pbkdf2Verify is shared by PBKDF2SHA256Hasher and PBKDF2SHA512Hasher; they differ only in scheme name and
+// hash constructor.
+func pbkdf2Verify(scheme HashScheme, encoded, password string, newHash func() hash.Hash) (bool, error) {
+	decodedScheme, params, salt, hashed, err := decodePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	if decodedScheme != scheme {
+		return false, ErrMalformedHash
+	}
+
+	values, err := parseParams(params)
+	if err != nil {
+		return false, err
+	}
+
+	iterations, errI := strconv.Atoi(values["i"])
+	if errI != nil {
+		return false, ErrMalformedHash
+	}
+
+	candidate := pbkdf2.Key([]byte(password), salt, iterations, len(hashed), newHash)
+
+	return subtle.ConstantTimeCompare(candidate, hashed) == 1, nil
+}
+
+// encodePHC assembles a PHC-style encoded hash string, "$scheme$params$<salt>$<hash>", with salt and hash
+// rendered as unpadded standard base64.
+func encodePHC(scheme HashScheme, params string, salt, hashed []byte) string {
+	return fmt.Sprintf("$%s$%s$%s$%s",
+		scheme, params, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hashed))
+}
+
+// decodePHC splits a PHC-style encoded hash string back into its scheme, raw (comma separated) parameter
+// string, salt and hash.
+func decodePHC(encoded string) (scheme HashScheme, params string, salt, hashed []byte, err error) {
+	// A well formed string is "$scheme$params$salt$hash", which splits into five parts: a leading empty
+	// string, then the four fields.
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[0] != "" {
+		return "", "", nil, nil, ErrMalformedHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", "", nil, nil, ErrMalformedHash
+	}
+
+	hashed, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", "", nil, nil, ErrMalformedHash
+	}
+
+	return HashScheme(parts[1]), parts[2], salt, hashed, nil
+}
+
+// encodeArgon2idPHC assembles the canonical 6-field Argon2id PHC string,
+// "$argon2id$v=<version>$<params>$<salt>$<hash>", with the version as its own $-delimited field (unlike
+// every other scheme here, which folds all its parameters into one field) so the result interoperates with
+// standard argon2 PHC parsers, not just this package's own decodeArgon2idPHC.
+func encodeArgon2idPHC(version int, params string, salt, hashed []byte) string {
+	return fmt.Sprintf("$%s$v=%d$%s$%s$%s", HashSchemeArgon2id, version, params,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hashed))
+}
+
+// decodeArgon2idPHC splits a canonical 6-field Argon2id PHC string back into its version, raw (comma
+// separated) parameter string, salt and hash.
+func decodeArgon2idPHC(encoded string) (version int, params string, salt, hashed []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || HashScheme(parts[1]) != HashSchemeArgon2id {
+		return 0, "", nil, nil, ErrMalformedHash
+	}
+
+	versionValues, err := parseParams(parts[2])
+	if err != nil {
+		return 0, "", nil, nil, ErrMalformedHash
+	}
+
+	version, err = strconv.Atoi(versionValues["v"])
+	if err != nil {
+		return 0, "", nil, nil, ErrMalformedHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return 0, "", nil, nil, ErrMalformedHash
+	}
+
+	hashed, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return 0, "", nil, nil, ErrMalformedHash
+	}
+
+	return version, parts[3], salt, hashed, nil
+}
+
+// parseParams parses a comma separated "k1=v1,k2=v2" parameter string, as produced by encodePHC's callers,
+// into a lookup map.
+func parseParams(params string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, pair := range strings.Split(params, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrMalformedHash
+		}
+
+		values[kv[0]] = kv[1]
+	}
+
+	return values, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fast cost parameters for every scheme, so the test suite doesn't pay production-grade cost on every run.
+var testHashers = map[password.HashScheme]password.Hasher{
+	password.HashSchemeBcrypt:       password.BcryptHasher{Cost: 4},
+	password.HashSchemeScrypt:       password.ScryptHasher{N: 16, R: 1, P: 1},
+	password.HashSchemeArgon2id:     password.Argon2idHasher{Params: password.Argon2Parameters{Time: 1, MemoryKiB: 8 * 1024, Parallelism: 1}},
+	password.HashSchemePBKDF2SHA256: password.PBKDF2SHA256Hasher{Iterations: 1},
+	password.HashSchemePBKDF2SHA512: password.PBKDF2SHA512Hasher{Iterations: 1},
+}
+
+// Test that every Hasher round-trips: Hash then Verify with the right password succeeds, and Verify with
+// the wrong password fails, for both the scheme-specific Verify and the scheme-dispatching package Verify.
+func TestHashersRoundTrip(t *testing.T) {
+	for scheme, hasher := range testHashers {
+		t.Run(string(scheme), func(t *testing.T) {
+			encoded, err := password.Hash("correct horse battery staple", hasher)
+			assert.Nil(t, err)
+
+			ok, err := hasher.Verify(encoded, "correct horse battery staple")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify(encoded, "wrong password")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+
+			ok, err = password.Verify(encoded, "correct horse battery staple")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+// Test that Verify rejects an encoded hash naming a scheme this package doesn't implement.
+func TestVerifyUnknownScheme(t *testing.T) {
+	_, err := password.Verify("$made-up-scheme$k=1$salt$hash", "anything")
+	assert.Equal(t, password.ErrUnknownHashScheme, err)
+}
+
+// Test that a scheme's Verify rejects a malformed encoded hash rather than panicking.
+func TestVerifyMalformedHash(t *testing.T) {
+	_, err := password.Argon2idHasher{}.Verify("$argon2id$not-enough-fields", "anything")
+	assert.Equal(t, password.ErrMalformedHash, err)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // SHA-1 is mandated by the HIBP range API's k-anonymity protocol.
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultHIBPEndpoint is the Have I Been Pwned Pwned Passwords range API, implementing k-anonymity:
+// https://haveibeenpwned.com/API/v3#PwnedPasswords
+const defaultHIBPEndpoint = "https://api.pwnedpasswords.com/range"
+
+// HIBPValidator rejects passwords that appear in the Have I Been Pwned breached password corpus. Only the
+// first 5 characters of the SHA-1 hash of the candidate password are ever sent to the API; the full set of
+// matching suffixes is compared locally, so the candidate password itself never leaves the process.
+type HIBPValidator struct {
+	// Endpoint is the base URL of the range API. Defaults to defaultHIBPEndpoint when empty, overridable
+	// for testing or for self-hosted corpora.
+	Endpoint string
+	// Client is the HTTP client used to query the range API. Defaults to http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// NewHIBPValidator constructs a HIBPValidator using the default HIBP range API endpoint.
+func NewHIBPValidator() *HIBPValidator {
+	return &HIBPValidator{}
+}
+
+// Name identifies this validator.
+func (v *HIBPValidator) Name() string {
+	return "hibp"
+}
+
+// Validate returns an error if the password appears in the HIBP breach corpus.
+func (v *HIBPValidator) Validate(password string) error {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	resp, err := v.client().Get(fmt.Sprintf("%s/%s", v.endpoint(), prefix))
+	if err != nil {
+		return fmt.Errorf("hibp: querying range api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hibp: range api returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if parts[0] != suffix {
+			continue
+		}
+
+		count, _ := strconv.Atoi(strings.TrimSpace(parts[1]))
+
+		return fmt.Errorf("password has appeared in %d known breaches", count)
+	}
+
+	return scanner.Err()
+}
+
+func (v *HIBPValidator) endpoint() string {
+	if v.Endpoint == "" {
+		return defaultHIBPEndpoint
+	}
+
+	return v.Endpoint
+}
+
+func (v *HIBPValidator) client() *http.Client {
+	if v.Client == nil {
+		return http.DefaultClient
+	}
+
+	return v.Client
+}
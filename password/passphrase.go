@@ -0,0 +1,196 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import (
+	"math"
+	"strings"
+
+	"github.com/kloeckner-i/can-haz-password/rand"
+)
+
+// Capitalization controls how individual words are cased when assembling a passphrase.
+type Capitalization int
+
+const (
+	// CapitalizeNone leaves every word exactly as supplied in the word list.
+	CapitalizeNone Capitalization = iota
+	// CapitalizeFirst capitalizes the first letter of every word.
+	CapitalizeFirst
+	// CapitalizeAll upper-cases every letter of every word.
+	CapitalizeAll
+	// CapitalizeRandom randomly upper-cases the first letter of each word, independently of the others.
+	CapitalizeRandom
+)
+
+// PassphraseWord is a single candidate word for a passphrase, with an optional relative weight.
+type PassphraseWord struct {
+	Value string
+	// Weight controls how likely this word is to be selected, relative to the other words in the list.
+	// A Weight of zero or less is treated as a Weight of 1, so a list with no weights set is sampled
+	// uniformly.
+	Weight float64
+}
+
+// PassphraseConfiguration sets the properties of a generated passphrase.
+type PassphraseConfiguration struct {
+	// WordCount is the number of words to assemble into the passphrase.
+	WordCount int
+	// Separator joins the words together (eg. "-" produces "correct-horse-battery-staple").
+	Separator string
+	// Capitalization controls the casing applied to each word.
+	Capitalization Capitalization
+	// IncludeDigit injects a random digit at a random position within the assembled passphrase.
+	IncludeDigit bool
+	// IncludeSymbol injects a random symbol, drawn from Symbols, at a random position within the
+	// assembled passphrase.
+	IncludeSymbol bool
+	// Symbols is the set of characters IncludeSymbol may choose from.
+	Symbols string
+	/* MinimumEntropyBits, when set, grows WordCount beyond its configured value until the passphrase meets
+	 * or exceeds the target entropy. This lets callers ask for a strength target (eg. 80 bits) without
+	 * hand calculating the word count required for a given list. Words are sampled with replacement, so
+	 * this can grow WordCount past the size of the word list itself.
+	 */
+	MinimumEntropyBits float64
+}
+
+// PassphraseRule is used to set the behavior of the passphrase generator.
+type PassphraseRule interface {
+	// Config returns the configuration associated with this rule.
+	Config() *PassphraseConfiguration
+	// Words returns the candidate word list (eg. a Diceware or EFF word list) to assemble the passphrase
+	// from.
+	Words() []PassphraseWord
+}
+
+// PassphraseGenerator generates memorable, word based passwords (aka. passphrases) matching a rule. This
+// mirrors the character based Generator, but samples whole words from a supplied word list instead of
+// individual characters from a character class.
+type PassphraseGenerator struct {
+	wordSource rand.RandomSet
+	words      []string
+	rule       PassphraseRule
+}
+
+// NewPassphraseGenerator constructs a passphrase generator from a rule.
+func NewPassphraseGenerator(rule PassphraseRule) PassphraseGenerator {
+	candidates := rule.Words()
+	words := make([]string, len(candidates))
+	entries := make([]rand.WeightedRandomEntry, len(candidates))
+
+	for i, w := range candidates {
+		words[i] = w.Value
+
+		weight := w.Weight
+		if weight <= 0.0 {
+			weight = 1.0
+		}
+
+		// The weighted random set operates on runes, so each word is identified by its index into words,
+		// rather than by its value.
+		entries[i] = rand.WeightedRandomEntry{Character: rune(i), Weight: weight}
+	}
+
+	return PassphraseGenerator{
+		// rand.NewBestRandomSet picks the alias method over the interval tree once the word list is large
+		// enough to amortize its table-building cost, which a sizeable Diceware-style list commonly is.
+		wordSource: rand.NewBestRandomSet(entries, rand.NewCryptoRand()),
+		words:      words,
+		rule:       rule,
+	}
+}
+
+// maxPassphraseWordCount caps how far Generate will grow WordCount to satisfy MinimumEntropyBits, as a
+// backstop against an unreasonable target (eg. a one-word list with an absurdly high entropy floor) growing
+// the passphrase without bound.
+const maxPassphraseWordCount = 256
+
+// Generate a new random passphrase.
+func (g PassphraseGenerator) Generate() (string, error) {
+	config := g.rule.Config()
+
+	wordCount := config.WordCount
+	for wordCount < maxPassphraseWordCount &&
+		config.MinimumEntropyBits > 0.0 &&
+		g.entropyOf(wordCount) < config.MinimumEntropyBits {
+		wordCount++
+	}
+
+	words := make([]string, wordCount)
+	for i := 0; i < wordCount; i++ {
+		words[i] = capitalize(g.words[int(g.wordSource.Next())], config.Capitalization)
+	}
+
+	passphrase := strings.Join(words, config.Separator)
+
+	rnd := rand.NewCryptoRand()
+
+	if config.IncludeDigit {
+		passphrase = insertAt(passphrase, rune(DigitCharacters[rnd.Intn(len(DigitCharacters))]), rnd.Intn(len([]rune(passphrase))+1))
+	}
+
+	if config.IncludeSymbol && config.Symbols != "" {
+		passphrase = insertAt(passphrase, rune(config.Symbols[rnd.Intn(len(config.Symbols))]), rnd.Intn(len([]rune(passphrase))+1))
+	}
+
+	return passphrase, nil
+}
+
+// entropyOf returns the Shannon entropy, in bits, of a passphrase made up of wordCount words drawn
+// uniformly from the candidate word list.
+func (g PassphraseGenerator) entropyOf(wordCount int) float64 {
+	return math.Log2(float64(len(g.words))) * float64(wordCount)
+}
+
+// capitalize applies a Capitalization style to a single word.
+func capitalize(word string, style Capitalization) string {
+	if word == "" {
+		return word
+	}
+
+	switch style {
+	case CapitalizeAll:
+		return strings.ToUpper(word)
+	case CapitalizeFirst:
+		return strings.ToUpper(word[:1]) + word[1:]
+	case CapitalizeRandom:
+		if rand.NewCryptoRand().Intn(2) == 0 {
+			return strings.ToUpper(word[:1]) + word[1:]
+		}
+
+		return word
+	default:
+		return word
+	}
+}
+
+// insertAt inserts a single rune into s at the given rune index, shifting everything from index onwards
+// one position to the right.
+func insertAt(s string, r rune, index int) string {
+	runes := []rune(s)
+	if index > len(runes) {
+		index = len(runes)
+	}
+
+	result := make([]rune, 0, len(runes)+1)
+	result = append(result, runes[:index]...)
+	result = append(result, r)
+	result = append(result, runes[index:]...)
+
+	return string(result)
+}
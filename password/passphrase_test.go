@@ -0,0 +1,79 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassphraseGenerator(t *testing.T) {
+	generator := password.NewPassphraseGenerator(newDummyPassphraseRule())
+
+	for i := 0; i < 1_000; i++ {
+		passphrase, err := generator.Generate()
+		assert.Nil(t, err)
+
+		words := strings.Split(passphrase, "-")
+		assert.Equal(t, 4, len(words))
+
+		for _, w := range words {
+			assert.Contains(t, []string{"Correct", "Horse", "Battery", "Staple"}, w)
+		}
+	}
+}
+
+func TestPassphraseGeneratorGrowsWordCountToMeetMinimumEntropy(t *testing.T) {
+	rule := newDummyPassphraseRule()
+	rule.config.MinimumEntropyBits = 16.0 // log2(4) == 2 bits/word, so we need 8 words.
+
+	generator := password.NewPassphraseGenerator(rule)
+
+	passphrase, err := generator.Generate()
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(strings.Split(passphrase, "-")))
+}
+
+type dummyPassphraseRule struct {
+	config *password.PassphraseConfiguration
+}
+
+func newDummyPassphraseRule() *dummyPassphraseRule {
+	return &dummyPassphraseRule{
+		config: &password.PassphraseConfiguration{
+			WordCount:      4,
+			Separator:      "-",
+			Capitalization: password.CapitalizeFirst,
+		},
+	}
+}
+
+func (r *dummyPassphraseRule) Config() *password.PassphraseConfiguration {
+	return r.config
+}
+
+func (r *dummyPassphraseRule) Words() []password.PassphraseWord {
+	return []password.PassphraseWord{
+		{Value: "correct"},
+		{Value: "horse"},
+		{Value: "battery"},
+		{Value: "staple"},
+	}
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import "strings"
+
+// Algorithm selects the strategy Generator uses to assemble password characters.
+type Algorithm int
+
+const (
+	// AlgorithmRandom draws each character class's Minimum characters from that class, fills the rest of
+	// the password from the weighted character classes in Configuration, then shuffles the result. This is
+	// the default, and the only algorithm the rest of this package implements.
+	AlgorithmRandom Algorithm = iota
+	/* AlgorithmPronounceable builds the password out of alternating consonant/vowel syllables, following
+	 * an APG/FIPS-181-style pseudo-word algorithm. The result reads and memorizes far more easily than an
+	 * equivalent-length AlgorithmRandom password, at the cost of some entropy per character. Use
+	 * Generator.GeneratePronounceable to additionally recover the syllable boundaries.
+	 */
+	AlgorithmPronounceable
+)
+
+// unitKind is the phonetic category of a syllable unit.
+type unitKind int
+
+const (
+	unitVowel unitKind = iota
+	unitConsonant
+)
+
+// unit is a single phonetic building block (a letter, or a digraph such as "ng") used to assemble a
+// syllable.
+type unit struct {
+	value string
+	kind  unitKind
+	// diphthong units may never appear twice in a row, but a diphthong next to a unit of the same kind
+	// satisfies the alternation rule that would otherwise forbid it (eg. vowel-diphthong next to vowel).
+	diphthong bool
+	// notBegin units may never open a password; things like "ng" and "qu" read oddly as the very first
+	// sound.
+	notBegin bool
+}
+
+// vowelUnits and consonantUnits are the two halves of the syllable alphabet that syllablePatterns draws
+// from. Single-letter units are ordinary vowels/consonants; multi-letter units are diphthongs.
+var vowelUnits = buildUnits(unitVowel, []string{
+	"a", "e", "i", "o", "u", "y",
+	"ae", "ah", "ai", "ee", "ei", "ie", "oh", "oo",
+}, nil)
+
+var consonantUnits = buildUnits(unitConsonant, []string{
+	"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+	"ch", "gh", "ng", "ph", "qu", "rh", "sh", "th", "ts", "tz", "wh", "zh",
+}, map[string]bool{"ng": true, "qu": true})
+
+func buildUnits(kind unitKind, values []string, notBegin map[string]bool) []unit {
+	units := make([]unit, len(values))
+
+	for i, v := range values {
+		units[i] = unit{value: v, kind: kind, diphthong: len(v) > 1, notBegin: notBegin[v]}
+	}
+
+	return units
+}
+
+// syllablePatterns are the syllable shapes construct draws from: consonant-vowel-consonant,
+// vowel-consonant, and consonant-vowel.
+var syllablePatterns = [][]unitKind{
+	{unitConsonant, unitVowel, unitConsonant},
+	{unitVowel, unitConsonant},
+	{unitConsonant, unitVowel},
+}
+
+// GeneratePronounceable generates a new password using the AlgorithmPronounceable syllable algorithm,
+// regardless of the Algorithm configured on the underlying Rule. Alongside the password itself, it
+// returns a hyphenated rendering of the same password with its syllable (and injected digit/symbol)
+// boundaries marked, eg. "ki-tor-nab-5-fes", so a caller can show a user how to read it back.
+func (g Generator) GeneratePronounceable() (string, string, error) {
+	const maxInvalidPasswordRejections = 10
+
+	config := g.passwordRule.Config()
+
+	targetLength, err := g.targetLength(config)
+	if err != nil {
+		return "", "", err
+	}
+
+	var lastValidatorErr error
+
+	for invalidPasswordRejections := 0; invalidPasswordRejections < maxInvalidPasswordRejections; invalidPasswordRejections++ {
+		syllables := g.constructPronounceable(config, targetLength)
+		password := strings.Join(syllables, "")
+
+		if !g.passwordRule.Valid([]rune(password)) {
+			continue
+		}
+
+		if err := runValidators(g.validators, password); err != nil {
+			lastValidatorErr = err
+
+			continue
+		}
+
+		return password, strings.Join(syllables, "-"), nil
+	}
+
+	if lastValidatorErr != nil {
+		return "", "", lastValidatorErr
+	}
+
+	return "", "", ErrInvalidPasswordRejection
+}
+
+/* constructPronounceable builds a single candidate password as a sequence of syllables: syllables are
+ * appended, in the CVC/VC/CV shapes of syllablePatterns, until the joined length reaches targetLength,
+ * then the Minimum count of every configured CharacterClasses entry (eg. digits, symbols) is spliced in
+ * at random syllable boundaries. It returns the individual syllables (and any injected characters,
+ * themselves treated as single-unit "syllables") rather than the assembled string, so the caller can
+ * also render the hyphenated, pronunciation-preserving form.
+ */
+func (g Generator) constructPronounceable(config *Configuration, targetLength int) []string {
+	syllables := make([]string, 0)
+	history := make([]unit, 0, 2)
+	length := 0
+
+	for length < targetLength {
+		pattern := syllablePatterns[g.randSource.Intn(len(syllablePatterns))]
+		syllable := strings.Builder{}
+
+		for _, kind := range pattern {
+			u := g.pickUnit(kind, len(syllables) == 0 && syllable.Len() == 0, history)
+			syllable.WriteString(u.value)
+
+			history = append(history, u)
+			if len(history) > 2 {
+				history = history[len(history)-2:]
+			}
+		}
+
+		syllables = append(syllables, syllable.String())
+		length += syllable.Len()
+	}
+
+	for _, characterClass := range config.CharacterClasses {
+		characters := []rune(characterClass.Characters)
+
+		for i := 0; i < characterClass.Minimum; i++ {
+			injected := string(characters[g.randSource.Intn(len(characters))])
+			boundary := g.randSource.Intn(len(syllables) + 1)
+
+			syllables = append(syllables, "")
+			copy(syllables[boundary+1:], syllables[boundary:])
+			syllables[boundary] = injected
+		}
+	}
+
+	return syllables
+}
+
+// pickUnit draws a single unit of the given kind from the syllable alphabet, subject to the syllable
+// construction rules: a notBegin unit may not open the password; two consecutive diphthongs are never
+// allowed; and two consecutive units of the same kind are only allowed when at least one is a diphthong.
+// history holds the last (up to) two units emitted so far, most recent last.
+func (g Generator) pickUnit(kind unitKind, isFirst bool, history []unit) unit {
+	units := vowelUnits
+	if kind == unitConsonant {
+		units = consonantUnits
+	}
+
+	// Bounded retry: the alphabet always contains single-letter, non-notBegin units of both kinds, so a
+	// valid candidate exists and this terminates quickly in practice.
+	const maxAttempts = 50
+
+	var candidate unit
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		candidate = units[g.randSource.Intn(len(units))]
+
+		if isFirst && candidate.notBegin {
+			continue
+		}
+
+		if len(history) == 0 {
+			break
+		}
+
+		last := history[len(history)-1]
+
+		if last.diphthong && candidate.diphthong {
+			continue
+		}
+
+		if last.kind == candidate.kind && !(last.diphthong || candidate.diphthong) {
+			continue
+		}
+
+		break
+	}
+
+	return candidate
+}
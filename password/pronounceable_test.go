@@ -0,0 +1,80 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPronounceableGenerator(t *testing.T) {
+	generator := password.NewGenerator(newDummyPronounceableRule())
+
+	for i := 0; i < 1_000; i++ {
+		generated, hyphenated, err := generator.GeneratePronounceable()
+		assert.Nil(t, err)
+
+		// constructPronounceable keeps appending syllables past Length, so the result may run a little long, but
+		// never short.
+		assert.Truef(t, len(generated) >= 12, "password '%v' was shorter than its configured Length", generated)
+
+		// Removing the hyphens used to mark syllable boundaries should reproduce the password exactly.
+		assert.Equal(t, generated, strings.ReplaceAll(hyphenated, "-", ""))
+
+		// The two guaranteed digits should have made it into the password somewhere.
+		digits := 0
+		for _, c := range generated {
+			if strings.ContainsRune(password.DigitCharacters, c) {
+				digits++
+			}
+		}
+		assert.Truef(t, digits >= 2, "password '%v' was missing its guaranteed digits", generated)
+	}
+}
+
+// Test that Generator.Generate dispatches to the pronounceable algorithm when configured to do so.
+func TestPronounceableGeneratorViaGenerate(t *testing.T) {
+	generator := password.NewGenerator(newDummyPronounceableRule())
+
+	generated, err := generator.Generate()
+	assert.Nil(t, err)
+	assert.Truef(t, len(generated) >= 12, "password '%v' was shorter than its configured Length", generated)
+}
+
+// A pronounceable rule with a fixed length and a couple of guaranteed digits.
+type dummyPronounceableRule struct{}
+
+func newDummyPronounceableRule() *dummyPronounceableRule {
+	return &dummyPronounceableRule{}
+}
+
+func (r *dummyPronounceableRule) Config() *password.Configuration {
+	return &password.Configuration{
+		Length:    12,
+		Algorithm: password.AlgorithmPronounceable,
+		CharacterClasses: []password.CharacterClassConfiguration{
+			{Characters: password.DigitCharacters, Minimum: 2},
+		},
+	}
+}
+
+func (r *dummyPronounceableRule) Valid(_ []rune) bool {
+	return true
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password
+
+import "fmt"
+
+// Validator performs an additional semantic check on a fully generated password, beyond the syntactic
+// constraints enforced by a Rule. Validators run after a candidate password has already satisfied its
+// Rule, and may reject it for reasons a Rule cannot express (eg. it appears in a breach corpus).
+type Validator interface {
+	// Name identifies the validator, used to report which validator rejected a password.
+	Name() string
+	// Validate returns nil if the password is acceptable, or an error describing why it was rejected.
+	Validate(password string) error
+}
+
+// ErrValidatorRejection is returned when a Generator's rejection budget runs out and a Validator in its
+// chain rejected at least one of the candidate passwords; it reports the most recent such rejection.
+type ErrValidatorRejection struct {
+	// Validator is the name of the Validator that rejected the password.
+	Validator string
+	// Reason is the underlying error returned by the Validator.
+	Reason error
+}
+
+func (e *ErrValidatorRejection) Error() string {
+	return fmt.Sprintf("password: validator %q rejected password: %v", e.Validator, e.Reason)
+}
+
+func (e *ErrValidatorRejection) Unwrap() error {
+	return e.Reason
+}
+
+// runValidators runs password through every Validator in the chain, in order, stopping at (and reporting)
+// the first rejection.
+func runValidators(validators []Validator, password string) error {
+	for _, v := range validators {
+		if err := v.Validate(password); err != nil {
+			return &ErrValidatorRejection{Validator: v.Name(), Reason: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package password_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/password"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeneratorRejectsPasswordsFailingValidatorChain ensures the generator runs the validator chain and
+// surfaces which validator rejected the password once the rejection budget is exhausted.
+func TestGeneratorRejectsPasswordsFailingValidatorChain(t *testing.T) {
+	generator := password.NewGeneratorWithValidators(newDummyPasswordRule(), &alwaysRejectValidator{})
+
+	_, err := generator.Generate()
+
+	var rejection *password.ErrValidatorRejection
+	assert.True(t, errors.As(err, &rejection))
+	assert.Equal(t, "always-reject", rejection.Validator)
+}
+
+// TestGeneratorAcceptsPasswordsPassingValidatorChain ensures a validator chain that never rejects does not
+// change the generator's normal behavior.
+func TestGeneratorAcceptsPasswordsPassingValidatorChain(t *testing.T) {
+	generator := password.NewGeneratorWithValidators(newDummyPasswordRule(), &neverRejectValidator{})
+
+	_, err := generator.Generate()
+	assert.Nil(t, err)
+}
+
+// alwaysRejectValidator rejects every password, used to exercise the generator's rejection budget.
+type alwaysRejectValidator struct{}
+
+func (v *alwaysRejectValidator) Name() string { return "always-reject" }
+
+func (v *alwaysRejectValidator) Validate(_ string) error {
+	return errors.New("rejected for testing")
+}
+
+// neverRejectValidator accepts every password.
+type neverRejectValidator struct{}
+
+func (v *neverRejectValidator) Name() string { return "never-reject" }
+
+func (v *neverRejectValidator) Validate(_ string) error { return nil }
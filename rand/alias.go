@@ -0,0 +1,170 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rand
+
+import (
+	"math"
+	mrand "math/rand"
+)
+
+/* AliasWeightedRandomSet is an alternative to WeightedRandomSet backed by Walker's alias method [1],
+ * instead of an interval tree. Sampling is O(1) rather than O(log n), at the cost of building two O(n)
+ * tables up front. This becomes worthwhile once n grows into the thousands, eg. a passphrase word list,
+ * where it removes the per-draw tree lookup from the hot path.
+ *
+ * [1] https://en.wikipedia.org/wiki/Alias_method
+ */
+type AliasWeightedRandomSet struct {
+	randSource *mrand.Rand
+	// The character associated with each column of the alias table.
+	characters []rune
+	// probability[i] is the probability of returning characters[i] directly when column i is selected,
+	// as opposed to its alias, characters[alias[i]].
+	probability []float64
+	// alias[i] is the column to defer to when column i is selected but its probability check fails.
+	alias []int
+	// The weight originally associated with each character, in the same order as characters. Retained
+	// so the probability distribution can be inspected (eg. for Entropy) without reconstructing it from
+	// the alias table.
+	weights []float64
+	// The total weight of the set (eg. the sum of all the entry weights).
+	totalWeight float64
+}
+
+// NewAliasWeightedRandomSet is used to construct a new alias-method weighted random set from a
+// collection of entries.
+func NewAliasWeightedRandomSet(entries []WeightedRandomEntry) AliasWeightedRandomSet {
+	return NewAliasWeightedRandomSetFromSource(entries, NewCryptoRand())
+}
+
+// NewAliasWeightedRandomSetFromSource constructs a new alias-method weighted random set from a
+// collection of entries, using randSource to drive selection instead of the default crypto/rand-backed
+// source.
+func NewAliasWeightedRandomSetFromSource(entries []WeightedRandomEntry, randSource *mrand.Rand) AliasWeightedRandomSet {
+	n := len(entries)
+
+	characters := make([]rune, n)
+	weights := make([]float64, n)
+	totalWeight := 0.0
+
+	for i, e := range entries {
+		characters[i] = e.Character
+		weights[i] = e.Weight
+		totalWeight += e.Weight
+	}
+
+	probability, alias := buildAliasTables(weights, totalWeight)
+
+	return AliasWeightedRandomSet{
+		randSource:  randSource,
+		characters:  characters,
+		probability: probability,
+		alias:       alias,
+		weights:     weights,
+		totalWeight: totalWeight,
+	}
+}
+
+// buildAliasTables runs Walker's alias method construction: each entry's weight is scaled so that its
+// average is 1 (`p_i = weight_i * n / totalWeight`), then entries below and at-or-above that average are
+// repeatedly paired off, donating the surplus probability of an over-average ("large") entry to the
+// deficit of an under-average ("small") one, until every column sums to exactly 1.
+func buildAliasTables(weights []float64, totalWeight float64) ([]float64, []int) {
+	n := len(weights)
+
+	scaled := make([]float64, n)
+	probability := make([]float64, n)
+	alias := make([]int, n)
+
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / totalWeight
+
+		if scaled[i] < 1.0 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		probability[s] = scaled[s]
+		alias[s] = l
+
+		// l donated (1 - scaled[s]) of its surplus to s; requeue it according to what remains.
+		scaled[l] -= 1.0 - scaled[s]
+
+		if scaled[l] < 1.0 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// Anything left over is only here due to floating point rounding, and is already ~1; treat it as
+	// exactly 1 so the column always returns itself rather than an uninitialized alias.
+	for _, i := range small {
+		probability[i] = 1.0
+	}
+
+	for _, i := range large {
+		probability[i] = 1.0
+	}
+
+	return probability, alias
+}
+
+// Next returns the next value in the weighted random sequence.
+func (rs AliasWeightedRandomSet) Next() rune {
+	column := rs.randSource.Intn(len(rs.characters))
+
+	if rs.randSource.Float64() < rs.probability[column] {
+		return rs.characters[column]
+	}
+
+	return rs.characters[rs.alias[column]]
+}
+
+// Size returns the number of discrete entries in this weighted random set.
+func (rs AliasWeightedRandomSet) Size() int {
+	return len(rs.characters)
+}
+
+// Entropy returns the Shannon entropy, in bits, of the probability distribution represented by this
+// weighted random set. See WeightedRandomSet.Entropy for details.
+func (rs AliasWeightedRandomSet) Entropy() float64 {
+	entropy := 0.0
+
+	for _, w := range rs.weights {
+		if w <= 0.0 {
+			continue
+		}
+
+		p := w / rs.totalWeight
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
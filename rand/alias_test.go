@@ -0,0 +1,94 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rand_test
+
+import (
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/rand"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensure the alias method sampler produces the same biased stream of characters as the interval tree
+// backed WeightedRandomSet, given the same triangle like distribution of weights.
+func TestAliasWeightedRandomSet(t *testing.T) {
+	entries := []rand.WeightedRandomEntry{
+		{Character: 0, Weight: 1.0},
+		{Character: 1, Weight: 2.0},
+		{Character: 2, Weight: 3.0},
+		{Character: 3, Weight: 4.0},
+		{Character: 4, Weight: 5.0},
+		{Character: 5, Weight: 4.0},
+		{Character: 6, Weight: 3.0},
+		{Character: 7, Weight: 2.0},
+		{Character: 8, Weight: 1.0},
+	}
+
+	rnd := rand.NewAliasWeightedRandomSet(entries)
+
+	const samples = 10_000
+	values := make([]rune, samples)
+
+	for i := 0; i < samples; i++ {
+		values[i] = rnd.Next()
+	}
+
+	probabilities := make([]float64, 9)
+	for _, v := range values {
+		probabilities[v] += 1.0
+	}
+
+	for v := range probabilities {
+		probabilities[v] /= float64(len(values))
+	}
+
+	expectedProbabilities := []float64{
+		0.04,
+		0.08,
+		0.12,
+		0.16,
+		0.2,
+		0.16,
+		0.12,
+		0.08,
+		0.04,
+	}
+
+	assert.True(t, approximatelyEqual(expectedProbabilities, probabilities, 0.02))
+}
+
+// A uniformly weighted set of n entries should have an entropy of log2(n) bits, matching
+// WeightedRandomSet (the two backends share the same probability distribution, only the sampling
+// algorithm differs).
+func TestAliasWeightedRandomSetEntropy(t *testing.T) {
+	uniform := rand.NewAliasWeightedRandomSet([]rand.WeightedRandomEntry{
+		{Character: 0, Weight: 1.0},
+		{Character: 1, Weight: 1.0},
+		{Character: 2, Weight: 1.0},
+		{Character: 3, Weight: 1.0},
+	})
+
+	assert.Equal(t, 4, uniform.Size())
+	assert.InDelta(t, 2.0, uniform.Entropy(), 0.0001)
+
+	biased := rand.NewAliasWeightedRandomSet([]rand.WeightedRandomEntry{
+		{Character: 0, Weight: 9.0},
+		{Character: 1, Weight: 1.0},
+	})
+
+	assert.True(t, biased.Entropy() < 1.0)
+}
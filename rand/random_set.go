@@ -0,0 +1,47 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rand
+
+import mrand "math/rand"
+
+// RandomSet is the common interface satisfied by WeightedRandomSet and AliasWeightedRandomSet, so callers
+// can select whichever backend suits their access pattern without the rest of their code caring which one
+// they got.
+type RandomSet interface {
+	// Next returns the next value in the weighted random sequence.
+	Next() rune
+	// Size returns the number of discrete entries in the set.
+	Size() int
+	// Entropy returns the Shannon entropy, in bits, of the set's probability distribution.
+	Entropy() float64
+}
+
+// aliasMethodThreshold is the entry count at or above which NewBestRandomSet selects AliasWeightedRandomSet over
+// WeightedRandomSet's interval tree. Below this size, the interval tree's O(n) construction and O(log n)
+// lookup already beat the alias method's O(n) table-building overhead; above it, the alias method's O(1)
+// Next() pays for itself on the hot path, eg. for a sizeable passphrase word list.
+const aliasMethodThreshold = 1024
+
+// NewBestRandomSet constructs a RandomSet from entries, using randSource to drive selection, picking
+// whichever of WeightedRandomSet or AliasWeightedRandomSet is the better backend for the entry count.
+func NewBestRandomSet(entries []WeightedRandomEntry, randSource *mrand.Rand) RandomSet {
+	if len(entries) >= aliasMethodThreshold {
+		return NewAliasWeightedRandomSetFromSource(entries, randSource)
+	}
+
+	return NewWeightedRandomSetFromSource(entries, randSource)
+}
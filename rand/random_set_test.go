@@ -0,0 +1,44 @@
+/*
+ * Copyright 2020 kloeckner.i GmbH
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rand_test
+
+import (
+	"testing"
+
+	"github.com/kloeckner-i/can-haz-password/rand"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that NewBestRandomSet selects the interval tree backend below aliasMethodThreshold, and the alias
+// method backend at or above it, while returning an equivalent distribution either way.
+func TestNewBestRandomSet(t *testing.T) {
+	small := make([]rand.WeightedRandomEntry, 10)
+	for i := range small {
+		small[i] = rand.WeightedRandomEntry{Character: rune(i), Weight: 1.0}
+	}
+
+	large := make([]rand.WeightedRandomEntry, 2000)
+	for i := range large {
+		large[i] = rand.WeightedRandomEntry{Character: rune(i), Weight: 1.0}
+	}
+
+	_, smallIsAlias := rand.NewBestRandomSet(small, rand.NewCryptoRand()).(rand.AliasWeightedRandomSet)
+	assert.False(t, smallIsAlias)
+
+	_, largeIsAlias := rand.NewBestRandomSet(large, rand.NewCryptoRand()).(rand.AliasWeightedRandomSet)
+	assert.True(t, largeIsAlias)
+}
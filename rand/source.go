@@ -20,8 +20,12 @@ package rand
 
 import (
 	crand "crypto/rand"
+	"crypto/sha512"
 	"encoding/binary"
+	"io"
 	mrand "math/rand"
+
+	"golang.org/x/crypto/hkdf"
 )
 
 // NewCryptoRand is used to construct a new `math/rand` instance that is backed by `crypto/rand`.
@@ -50,3 +54,39 @@ func (r cryptoRandomSource) Uint64() (v uint64) {
 
 	return v
 }
+
+/* NewSeededSource constructs a new `math/rand` instance whose entire output stream is deterministically
+ * derived from seed, in place of an entropy source. It is backed by an HKDF-SHA512 expansion of seed,
+ * drained eight bytes at a time.
+ *
+ * Given the same seed, it reproduces the exact same sequence of values on every call, across runs and
+ * platforms. This is what allows password.NewDeterministicGenerator to re-derive a password from a
+ * master secret instead of storing it.
+ */
+func NewSeededSource(seed []byte) *mrand.Rand {
+	return mrand.New(&seededSource{expander: hkdf.New(sha512.New, seed, nil, nil)})
+}
+
+// seededSource is a `math/rand` compatible source that drains a deterministic HKDF expand stream instead
+// of reading from an entropy source.
+type seededSource struct {
+	expander io.Reader
+}
+
+// Seeding is explicitly ignored; the source's output is already fully determined by the seed passed to
+// NewSeededSource.
+func (r *seededSource) Seed(_ int64) {}
+
+func (r *seededSource) Int63() int64 {
+	return int64(r.Uint64() & ^uint64(1<<63))
+}
+
+// Read the next eight bytes of the HKDF expand output.
+func (r *seededSource) Uint64() (v uint64) {
+	err := binary.Read(r.expander, binary.BigEndian, &v)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
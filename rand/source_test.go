@@ -54,3 +54,21 @@ func TestCryptoRandomSource(t *testing.T) {
 	pi := 4.0 * float64(circle) / float64(square)
 	assert.True(t, math.Abs(pi-math.Pi) < 0.1)
 }
+
+// Test that a seeded source is fully deterministic, and that distinct seeds diverge.
+func TestSeededSource(t *testing.T) {
+	seed := []byte("a master secret")
+
+	first := rand.NewSeededSource(seed)
+	second := rand.NewSeededSource(seed)
+	other := rand.NewSeededSource([]byte("a different master secret"))
+
+	for i := 0; i < 100; i++ {
+		a := first.Uint64()
+		b := second.Uint64()
+		c := other.Uint64()
+
+		assert.Equal(t, a, b)
+		assert.NotEqual(t, a, c)
+	}
+}
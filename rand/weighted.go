@@ -17,7 +17,8 @@
 package rand
 
 import (
-	"math/rand"
+	"math"
+	mrand "math/rand"
 
 	"github.com/geozelot/intree"
 )
@@ -36,11 +37,15 @@ import (
 //
 // [1] https://en.wikipedia.org/wiki/Interval_tree
 type WeightedRandomSet struct {
-	randSource *rand.Rand
+	randSource *mrand.Rand
 	// An interval tree used internally in the weighting algorithm.
 	tree *intree.INTree
 	// The character associated with each element in the interval tree.
 	characters []rune
+	// The weight originally associated with each character, in the same order as characters. Retained
+	// alongside the interval tree so the probability distribution can be inspected (eg. for Entropy)
+	// without having to walk the tree.
+	weights []float64
 	// The total weight of the tree (eg. the sum of all the entry weights).
 	totalWeight float64
 }
@@ -53,20 +58,30 @@ type WeightedRandomEntry struct {
 
 // NewWeightedRandomSet is used to construct a new weighted random set from a collection of entries.
 func NewWeightedRandomSet(entries []WeightedRandomEntry) WeightedRandomSet {
+	return NewWeightedRandomSetFromSource(entries, NewCryptoRand())
+}
+
+// NewWeightedRandomSetFromSource constructs a new weighted random set from a collection of entries, using
+// randSource to drive selection instead of the default crypto/rand-backed source. This is primarily used
+// to build a deterministic WeightedRandomSet from a rand.NewSeededSource.
+func NewWeightedRandomSetFromSource(entries []WeightedRandomEntry, randSource *mrand.Rand) WeightedRandomSet {
 	totalWeight := 0.0
 	ranges := make([]intree.Bounds, len(entries))
 	characters := make([]rune, len(entries))
+	weights := make([]float64, len(entries))
 
 	for i, w := range entries {
 		ranges[i] = &interval{min: totalWeight, max: totalWeight + w.Weight}
 		characters[i] = w.Character
+		weights[i] = w.Weight
 		totalWeight += w.Weight
 	}
 
 	return WeightedRandomSet{
-		randSource:  NewCryptoRand(),
+		randSource:  randSource,
 		tree:        intree.NewINTree(ranges),
 		characters:  characters,
+		weights:     weights,
 		totalWeight: totalWeight,
 	}
 }
@@ -83,6 +98,29 @@ func (rs WeightedRandomSet) Next() rune {
 	return rs.characters[index]
 }
 
+// Size returns the number of discrete entries in this weighted random set.
+func (rs WeightedRandomSet) Size() int {
+	return len(rs.characters)
+}
+
+// Entropy returns the Shannon entropy, in bits, of the probability distribution represented by this
+// weighted random set (-Σ p_i log2 p_i). This reflects any bias introduced by non-uniform weights; a
+// uniformly weighted set of n entries has an entropy of log2(n) bits, as expected.
+func (rs WeightedRandomSet) Entropy() float64 {
+	entropy := 0.0
+
+	for _, w := range rs.weights {
+		if w <= 0.0 {
+			continue
+		}
+
+		p := w / rs.totalWeight
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
 // A simple floating point interval implementation for the interval tree.
 type interval struct {
 	min, max float64
@@ -78,6 +78,26 @@ func TestWeightedRandomSet(t *testing.T) {
 	assert.True(t, approximatelyEqual(expectedProbabilities, probabilities, 0.02))
 }
 
+// A uniformly weighted set of n entries should have an entropy of log2(n) bits, and a biased one strictly less.
+func TestWeightedRandomSetEntropy(t *testing.T) {
+	uniform := rand.NewWeightedRandomSet([]rand.WeightedRandomEntry{
+		{Character: 0, Weight: 1.0},
+		{Character: 1, Weight: 1.0},
+		{Character: 2, Weight: 1.0},
+		{Character: 3, Weight: 1.0},
+	})
+
+	assert.Equal(t, 4, uniform.Size())
+	assert.InDelta(t, 2.0, uniform.Entropy(), 0.0001)
+
+	biased := rand.NewWeightedRandomSet([]rand.WeightedRandomEntry{
+		{Character: 0, Weight: 9.0},
+		{Character: 1, Weight: 1.0},
+	})
+
+	assert.True(t, biased.Entropy() < 1.0)
+}
+
 // Compare an array of floating point values for approximate equality, epsilon being an upper bound on the error.
 func approximatelyEqual(expected, actual []float64, epsilon float64) bool {
 	for i, v := range expected {